@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	_auditRepo "transaction-service/audit/repository/postgres"
+	_auditUsecase "transaction-service/audit/usecase"
+	"transaction-service/config"
+	"transaction-service/migrations"
+
+	"github.com/rs/zerolog/log"
+)
+
+// runCommand executes an operational subcommand instead of starting the server.
+func runCommand(cfg *config.Config, args []string) {
+	switch args[0] {
+	case "verify-audit":
+		os.Exit(verifyAudit(cfg))
+	case "migrate":
+		os.Exit(migrate(cfg, args[1:]))
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", args[0], usage)
+		os.Exit(2)
+	}
+}
+
+var usage = `usage: main [-config file] [command]
+
+  main                      start the server
+  main verify-audit         verify the audit log hash chain
+` + migrations.Usage("main")
+
+func migrate(cfg *config.Config, args []string) int {
+	db := connectDB(cfg)
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Err(err).Msg("load migrations error")
+		return 1
+	}
+	if err := migrations.RunCommand(context.Background(), migrator, args, os.Stdout); err != nil {
+		if errors.Is(err, migrations.ErrUsage) {
+			fmt.Fprintf(os.Stderr, "%v\n%s", err, usage)
+			return 2
+		}
+		log.Err(err).Msg("migrate error")
+		return 1
+	}
+	return 0
+}
+
+// verifyAudit walks the audit chain and reports the first broken link.
+func verifyAudit(cfg *config.Config) int {
+	db := connectDB(cfg)
+	defer db.Close()
+
+	auditRepo := _auditRepo.NewAuditRepository(db)
+	auditUsecase := _auditUsecase.NewAuditUsecase(auditRepo, []byte(cfg.Token.Secret), cfg.Timeout)
+
+	result, err := auditUsecase.VerifyChain(context.Background())
+	if err != nil {
+		log.Err(err).Msg("verify audit chain error")
+		return 1
+	}
+	if !result.OK() {
+		fmt.Printf("audit chain broken at record %d: %s\n", result.BrokenEventID, result.Reason)
+		return 1
+	}
+	fmt.Printf("audit chain intact: %d records verified, %d of them redacted, %d legacy records, %d checkpoints\n",
+		result.Checked, result.Redacted, result.Legacy, result.Checkpoints)
+	return 0
+}
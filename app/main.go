@@ -2,128 +2,319 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	_auditHandler "transaction-service/audit/delivery/http"
+	_auditRepo "transaction-service/audit/repository/postgres"
+	_auditUsecase "transaction-service/audit/usecase"
+	"transaction-service/config"
+	"transaction-service/connection"
 	"transaction-service/domain"
+	"transaction-service/health"
+	"transaction-service/httperror"
+	"transaction-service/lifecycle"
+	"transaction-service/logging"
+	"transaction-service/metrics"
+	"transaction-service/migrations"
+	"transaction-service/tracing"
 	_handler "transaction-service/users/delivery/http"
 	_repo "transaction-service/users/repository/postgres"
 	_redis "transaction-service/users/repository/redis"
 	_usecase "transaction-service/users/usecase"
+	utils "transaction-service/utils"
 
 	"github.com/rs/zerolog/log"
 
-	"github.com/go-redis/redis"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/labstack/echo/v4"
-	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
-func init() {
-	viper.SetConfigFile(`config.json`)
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatal().Err(err).Msg("get configuration error")
+func main() {
+	configFile := flag.String("config", "", "path to the configuration file (default $AUTH_CONFIG or "+config.DefaultPath+")")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-}
 
-func main() {
+	if flag.NArg() > 0 {
+		runCommand(cfg, flag.Args())
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	lc := lifecycle.NewManager(cfg.Shutdown.DrainDelay, cfg.Shutdown.Timeout)
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatal().Err(err).Msg("tracing configuration error")
+	}
+	// registered first so the spans of the shutdown itself are flushed
+	lc.OnShutdown("tracing", shutdownTracing)
+
+	client := connection.NewRedis(cfg.Redis)
+	lc.OnShutdown("redis", func(context.Context) error { return client.Close() })
+
+	token := cfg.JwtToken()
+	redis := metrics.JwtTokenRepo(_redis.NewRedisRepo(client))
+	timeout := cfg.Timeout
+
+	db, err := connection.NewPostgres(cfg.Postgres)
+	if err != nil {
+		log.Fatal().Err(err).Msg("postgres configuration error")
+	}
+	lc.OnShutdown("postgres", func(context.Context) error {
+		db.Close()
+		return nil
+	})
+	lc.OnShutdown("workers", lc.StopWorkers)
+	metrics.RegisterPool(db)
+
+	policy, err := cfg.Password.Policy()
+	if err != nil {
+		log.Fatal().Err(err).Msg("password policy configuration error")
+	}
+
+	keyring, err := cfg.Encryption.Keyring()
+	if err != nil {
+		log.Fatal().Err(err).Msg("encryption configuration error")
+	}
 
-	client := connectRedis()
+	userRepo := metrics.UserRepository(_repo.NewUserRepository(db, keyring))
+	userUsecase := _usecase.NewUserUseCase(userRepo, timeout, policy, cfg.Registration.MinAge)
+	jwtUsecase := _usecase.NewJWTUseCase(token, redis, metrics.SigningKeyRepository(_repo.NewSigningKeyRepository(db, keyring)))
+	impRepo := metrics.ImpersonationRepository(_repo.NewImpersonationRepository(db))
+	impUsecase := _usecase.NewImpersonationUsecase(userRepo, impRepo, timeout)
+	erasureUsecase := _usecase.NewErasureUsecase(userRepo, jwtUsecase, cfg.Erasure.GracePeriod, cfg.Erasure.BatchSize, timeout)
+	companyRepo := metrics.CompanyRepository(_repo.NewCompanyRepository(db))
+	companyUsecase := _usecase.NewCompanyUsecase(userRepo, companyRepo, timeout)
+	auditRepo := metrics.AuditRepository(_auditRepo.NewAuditRepository(db))
+	auditUsecase := _auditUsecase.NewAuditUsecase(auditRepo, []byte(token.AccessSecret), timeout)
+	exportUsecase := _usecase.NewExportUsecase(userRepo, companyRepo, auditRepo, jwtUsecase, timeout)
 
-	token := domain.JwtToken{
-		AccessSecret: viper.GetString(`token.secret`),
-		AccessTtl:    viper.GetDuration(`token.ttl`) * time.Minute,
+	hc := health.New(cfg.Health.CacheTTL, lc.Ready)
+	hc.Register("postgres", cfg.Health.Timeout, true, func(ctx context.Context) error { return connection.PingPostgres(ctx, db) })
+	hc.Register("redis", cfg.Health.Timeout, true, func(ctx context.Context) error { return connection.PingRedis(ctx, client) })
+	if cfg.Health.TransactionServiceURL != "" {
+		hc.Register("transaction-service", cfg.Health.Timeout, false, health.HTTPCheck(metrics.Client("transaction-service", cfg.Health.Timeout), cfg.Health.TransactionServiceURL))
 	}
-	redis := _redis.NewRedisRepo(client)
-	timeout := viper.GetDuration(`timeout`) * time.Second
 
-	db := connectDB()
-	defer db.Close()
+	// the server starts right away and reports starting until the dependencies
+	// are reachable and the schema is up to date
+	lc.Go("startup", func(ctx context.Context) {
+		if err := connection.Retry(ctx, "postgres", cfg.Health.MaxBackoff, func(ctx context.Context) error { return connection.PingPostgres(ctx, db) }); err != nil {
+			return
+		}
+		// a failure ends the process through the lifecycle manager, so the
+		// shutdown hooks still run
+		if err := initDB(db); err != nil {
+			lc.Fail(err)
+			return
+		}
+		warnPlaintextIINs(_repo.NewIINReencryptor(db, keyring))
+		if err := bootstrapAdmin(userUsecase, cfg.Admin); err != nil {
+			lc.Fail(err)
+			return
+		}
+		if err := jwtUsecase.ReloadSigningKeys(ctx); err != nil {
+			lc.Fail(fmt.Errorf("load signing keys: %w", err))
+			return
+		}
+		if err := connection.Retry(ctx, "redis", cfg.Health.MaxBackoff, func(ctx context.Context) error { return connection.PingRedis(ctx, client) }); err != nil {
+			return
+		}
 
-	userRepo := _repo.NewUserRepository(db)
-	userUsecase := _usecase.NewUserUseCase(userRepo, timeout)
-	jwtUsecase := _usecase.NewJWTUseCase(token, redis)
+		lc.Go("key refresh", func(ctx context.Context) { runKeyRefresh(ctx, jwtUsecase, cfg.Token.KeyRefresh) })
+		lc.Go("audit checkpoints", func(ctx context.Context) { runAuditCheckpoints(ctx, auditUsecase, cfg.Audit.CheckpointInterval) })
+		lc.Go("erasure", func(ctx context.Context) { runErasure(ctx, erasureUsecase, auditUsecase, cfg.Erasure.Interval) })
+		hc.MarkStarted()
+		log.Info().Msg("startup complete")
+	})
 
 	e := echo.New()
-	_handler.NewUserHandler(e, userUsecase, jwtUsecase)
+	e.HTTPErrorHandler = httperror.Handler
+	e.Use(otelecho.Middleware(cfg.Tracing.ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		switch c.Path() {
+		case "/metrics", "/healthz", "/readyz":
+			return true
+		}
+		return false
+	})))
+	e.Use(logging.Middleware)
+	e.Use(metrics.Middleware)
+	e.GET("/metrics", metrics.Handler())
+	e.GET("/healthz", hc.Liveness)
+	e.GET("/readyz", hc.Readiness)
+	_handler.NewUserHandler(e, userUsecase, jwtUsecase, impUsecase, auditUsecase, companyUsecase, exportUsecase)
+	_auditHandler.NewAuditHandler(e, auditUsecase, jwtUsecase, userUsecase)
+	lc.OnShutdown("http", e.Shutdown)
 
-	err := e.Start(viper.GetString(`addr`))
-	if err != nil && err != http.ErrServerClosed {
+	go func() {
+		// a second signal kills the process without waiting for the drain
+		<-ctx.Done()
+		stop()
+	}()
+	if err := lc.Run(ctx, func() error { return e.Start(cfg.Addr) }); err != nil {
 		log.Fatal().Err(err).Msg(`shutting down the server`)
 	}
+	log.Info().Msg("server stopped")
 }
 
-func connectRedis() *redis.Client {
+// runAuditCheckpoints periodically signs the head of the audit chain.
+func runAuditCheckpoints(ctx context.Context, au domain.AuditUsecase, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	address := viper.GetString(`redis.address`)
-	password := viper.GetString(`redis.password`)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		checkpoint, err := au.CreateCheckpoint(ctx)
+		if err != nil {
+			log.Err(err).Msg("audit checkpoint error")
+			continue
+		}
+		if checkpoint != nil {
+			log.Info().Int64("event", checkpoint.EventID).Msg("audit checkpoint created")
+		}
+	}
+}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     address,
-		Password: password,
-		DB:       0,
-	})
+// runErasure erases the accounts whose grace period is over, every erasure is
+// recorded in the audit log.
+func runErasure(ctx context.Context, eu domain.ErasureUsecase, au domain.AuditUsecase, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	_, err := client.Ping().Result()
-	if err != nil {
-		log.Fatal().Err(err).Msg("redis ping error")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		erased, err := eu.EraseDeleted(ctx, time.Now())
+		if err != nil {
+			log.Err(err).Msg("erasure error")
+		}
+		for _, id := range erased {
+			if err := au.RecordEvent(ctx, &domain.AuditEvent{Action: domain.AuditUserErase, TargetID: id, Details: "personal data erased"}); err != nil {
+				log.Err(err).Int64("user", id).Msg("cannot record audit event")
+			}
+		}
+		if len(erased) > 0 {
+			log.Info().Int("count", len(erased)).Msg("deleted accounts erased")
+		}
 	}
-	return client
 }
 
-func connectDB() *pgxpool.Pool {
-
-	username := viper.GetString(`postgres.user`)
-	password := viper.GetString(`postgres.password`)
-	hostname := viper.GetString(`postgres.host`)
-	port := viper.GetInt(`postgres.port`)
-	dbname := viper.GetString(`postgres.dbname`)
+// runKeyRefresh picks up signing keys rotated by authctl.
+func runKeyRefresh(ctx context.Context, ju domain.JwtTokenUsecase, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s", username, password, hostname, port, dbname)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := ju.ReloadSigningKeys(ctx); err != nil {
+			log.Err(err).Msg("reload signing keys error")
+		}
+	}
+}
 
-	config, err := pgxpool.ParseConfig(dsn)
+func connectDB(cfg *config.Config) *pgxpool.Pool {
+	db, err := connection.Postgres(cfg.Postgres)
 	if err != nil {
-		log.Fatal().Err(err).Msg("parse dsn config error")
+		log.Fatal().Err(err).Msg("postgres connection error")
 	}
+	return db
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+// initDB brings the schema up to date.
+func initDB(db *pgxpool.Pool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
-	db, err := pgxpool.ConnectConfig(ctx, config)
+
+	migrator, err := migrations.NewMigrator(db)
 	if err != nil {
-		log.Fatal().Err(err).Msg("connect postgres error")
-	}
-
-	if err := db.Ping(ctx); err != nil {
-		log.Fatal().Err(err).Msg("db ping error")
-	}
-	//TODO: move all to init.sql
-	//temporary
-
-	// _, err = db.Exec(ctx, `
-	// DROP TABLE users;
-	// `)
-	// if err != nil {
-	// 	log.Fatalf("Drop table error: %v", err)
-	// }
-	_, err = db.Exec(ctx, `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY UNIQUE,
-		username VARCHAR (255) NOT NULL UNIQUE,
-		password TEXT NOT NULL,
-		iin VARCHAR (255) NOT NULL UNIQUE,
-		role VARCHAR (24) NOT NULL,
-		registerDate TEXT NOT NULL
-	);
-	`)
+		return fmt.Errorf("load migrations: %w", err)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	for _, m := range applied {
+		log.Info().Int64("version", m.Version).Str("name", m.Name).Msg("migration applied")
+	}
+	return nil
+}
+
+// warnPlaintextIINs reports the IINs stored before the encryption was
+// introduced. The service only reads them, authctl reencrypt-iin seals them.
+func warnPlaintextIINs(iins *_repo.IINReencryptor) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := iins.Plaintext(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Create table error")
+		log.Err(err).Msg("count plaintext IINs error")
+		return
+	}
+	if count > 0 {
+		log.Warn().Int64("count", count).Msg("IINs stored in plaintext, run authctl reencrypt-iin")
+	}
+}
+
+// bootstrapAdmin creates the initial admin when none exists. The IIN must be
+// set in AUTH_ADMIN_IIN, the password is taken from AUTH_ADMIN_PASSWORD or
+// generated and printed exactly once.
+func bootstrapAdmin(uc domain.UserUsecase, cfg config.Admin) error {
+	password := os.Getenv("AUTH_ADMIN_PASSWORD")
+	generated := password == ""
+	if generated {
+		var err error
+		if password, err = utils.GeneratePassword(16); err != nil {
+			return fmt.Errorf("generate admin password: %w", err)
+		}
+	}
+
+	admin := &domain.User{
+		Username: cfg.Username,
+		IIN:      cfg.IIN,
+		Password: password,
 	}
-	_, err = db.Exec(ctx,
-		`INSERT INTO users(username, password, iin, role, registerDate) VALUES ($1, $2, $3, $4, $5)`,
-		"admin", "pass", "940217200216", "admin", time.Now().Format("2006-01-02 15:04:05"))
+	created, err := uc.BootstrapAdminUsecase(context.Background(), admin)
 	if err != nil {
-		log.Printf("Admin already exist: %v", err)
+		return fmt.Errorf("bootstrap admin: %w", err)
 	}
-	return db
+	if !created {
+		return nil
+	}
+	log.Info().Str("username", admin.Username).Msg("bootstrap admin created")
+	if generated {
+		fmt.Printf("\nInitial admin %q created with password: %s\nIt will not be shown again, change it after the first login.\n\n", admin.Username, password)
+	}
+	return nil
 }
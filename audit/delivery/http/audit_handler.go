@@ -0,0 +1,149 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"transaction-service/domain"
+	"transaction-service/httperror"
+	config "transaction-service/users/delivery/http/middleware"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type AuditHandler struct {
+	AuditUsecase domain.AuditUsecase
+}
+
+type auditView struct {
+	Page   *domain.AuditPage
+	Filter url.Values
+	Prev   string
+	Next   string
+}
+
+func NewAuditHandler(e *echo.Echo, au domain.AuditUsecase, jwt domain.JwtTokenUsecase, us domain.UserUsecase) {
+	handler := &AuditHandler{AuditUsecase: au}
+	midd := config.InitAuthorization(jwt, us)
+
+	auditGroup := e.Group("/audit")
+	auditGroup.Use(middleware.JWTWithConfig(midd.GetConfig()))
+
+	auditGroup.GET("", handler.AuditPage)
+	auditGroup.GET("/events", handler.ListEvents, httperror.JSON)
+}
+
+func (a *AuditHandler) AuditPage(e echo.Context) error {
+	if err := checkAuditAccess(e); err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(e)
+	if err != nil {
+		return domain.Validation(domain.CodeInvalidInput, err.Error(), err)
+	}
+	ctx := e.Request().Context()
+	page, err := a.AuditUsecase.ListEvents(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	view := auditView{Page: page, Filter: e.QueryParams()}
+	if page.Offset > 0 {
+		view.Prev = pageQuery(e.QueryParams(), page.Offset-page.Limit)
+	}
+	if int64(page.Offset+page.Limit) < page.Total {
+		view.Next = pageQuery(e.QueryParams(), page.Offset+page.Limit)
+	}
+	return e.Render(http.StatusOK, "audit.html", view)
+}
+
+func (a *AuditHandler) ListEvents(e echo.Context) error {
+	if err := checkAuditAccess(e); err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(e)
+	if err != nil {
+		return domain.Validation(domain.CodeInvalidInput, err.Error(), err)
+	}
+	ctx := e.Request().Context()
+	page, err := a.AuditUsecase.ListEvents(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return e.JSON(http.StatusOK, page)
+}
+
+func checkAuditAccess(e echo.Context) error {
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+	if !domain.HasPermission(meta.Role, domain.PermAuditRead) {
+		return domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("user %d with role %q cannot read audit log", meta.ID, meta.Role))
+	}
+	return nil
+}
+
+func parseFilter(e echo.Context) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{Action: e.QueryParam("action")}
+
+	ints := []struct {
+		name  string
+		value *int64
+	}{{"actor", &filter.ActorID}, {"target", &filter.TargetID}}
+	for _, param := range ints {
+		if raw := e.QueryParam(param.name); raw != "" {
+			v, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s: %q", param.name, raw)
+			}
+			*param.value = v
+		}
+	}
+
+	if raw := e.QueryParam("from"); raw != "" {
+		from, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date: %q", raw)
+		}
+		filter.From = from
+	}
+	if raw := e.QueryParam("to"); raw != "" {
+		to, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date: %q", raw)
+		}
+		// the to date is inclusive
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	var err error
+	if raw := e.QueryParam("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			return filter, fmt.Errorf("invalid limit: %q", raw)
+		}
+	}
+	if raw := e.QueryParam("offset"); raw != "" {
+		if filter.Offset, err = strconv.Atoi(raw); err != nil {
+			return filter, fmt.Errorf("invalid offset: %q", raw)
+		}
+	}
+	return filter, nil
+}
+
+func pageQuery(query url.Values, offset int) string {
+	if offset < 0 {
+		offset = 0
+	}
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("offset", strconv.Itoa(offset))
+	return "/audit?" + q.Encode()
+}
@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"transaction-service/domain"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// auditChainLock is the advisory lock key serializing writers of the audit chain.
+const auditChainLock = 7260029
+
+type auditRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewAuditRepository(Conn *pgxpool.Pool) domain.AuditRepository {
+	return &auditRepository{Conn}
+}
+
+func (a *auditRepository) InsertEvent(ctx context.Context, event *domain.AuditEvent) error {
+
+	tx, err := a.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db begin audit event: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", auditChainLock); err != nil {
+		return fmt.Errorf("db lock audit chain: %w", err)
+	}
+	prevHash := ""
+	if err := tx.QueryRow(ctx, "SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&prevHash); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("db audit chain head: %w", err)
+	}
+	if err := event.NewSalts(); err != nil {
+		return fmt.Errorf("audit event salts: %w", err)
+	}
+	event.PrevHash = prevHash
+	event.Hash = event.ComputeHash(prevHash)
+
+	if err := tx.QueryRow(ctx, `INSERT INTO audit_log(action, actor_id, target_id, ip, user_agent, details, created_at, prev_hash, hash, salts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		event.Action, nullID(event.ActorID), nullID(event.TargetID), event.IP, event.UserAgent, event.Details, event.CreatedAt,
+		event.PrevHash, event.Hash, event.Salts).Scan(&event.ID); err != nil {
+		return fmt.Errorf("db insert audit event: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (a *auditRepository) ListEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, int64, error) {
+
+	where, args := filterClause(filter)
+
+	var total int64
+	if err := a.Conn.QueryRow(ctx, "SELECT count(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("db count audit events: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	events, err := a.queryEvents(ctx, fmt.Sprintf(`SELECT %s FROM audit_log%s ORDER BY id DESC LIMIT $%d OFFSET $%d`,
+		eventColumns, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("db list audit events: %w", err)
+	}
+	return events, total, nil
+}
+
+func (a *auditRepository) ListChain(ctx context.Context, afterID int64, limit int) ([]domain.AuditEvent, error) {
+
+	events, err := a.queryEvents(ctx, fmt.Sprintf(`SELECT %s FROM audit_log WHERE id>$1 ORDER BY id LIMIT $2`, eventColumns),
+		afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db list audit chain: %w", err)
+	}
+	return events, nil
+}
+
+func (a *auditRepository) LastEvent(ctx context.Context) (*domain.AuditEvent, error) {
+
+	events, err := a.queryEvents(ctx, fmt.Sprintf(`SELECT %s FROM audit_log ORDER BY id DESC LIMIT 1`, eventColumns))
+	if err != nil {
+		return nil, fmt.Errorf("db last audit event: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+func (a *auditRepository) InsertCheckpoint(ctx context.Context, checkpoint *domain.AuditCheckpoint) error {
+
+	if err := a.Conn.QueryRow(ctx, `INSERT INTO audit_checkpoints(event_id, hash, signature, created_at)
+		VALUES ($1, $2, $3, $4) RETURNING id`,
+		checkpoint.EventID, checkpoint.Hash, checkpoint.Signature, checkpoint.CreatedAt).Scan(&checkpoint.ID); err != nil {
+		return fmt.Errorf("db insert audit checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (a *auditRepository) LastCheckpoint(ctx context.Context) (*domain.AuditCheckpoint, error) {
+
+	checkpoint := &domain.AuditCheckpoint{}
+	err := a.Conn.QueryRow(ctx, "SELECT id, event_id, hash, signature, created_at FROM audit_checkpoints ORDER BY id DESC LIMIT 1").
+		Scan(&checkpoint.ID, &checkpoint.EventID, &checkpoint.Hash, &checkpoint.Signature, &checkpoint.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("db last audit checkpoint: %w", err)
+	}
+	return checkpoint, nil
+}
+
+func (a *auditRepository) ListCheckpoints(ctx context.Context) ([]domain.AuditCheckpoint, error) {
+
+	rows, err := a.Conn.Query(ctx, "SELECT id, event_id, hash, signature, created_at FROM audit_checkpoints ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("db list audit checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	checkpoints := []domain.AuditCheckpoint{}
+	for rows.Next() {
+		checkpoint := domain.AuditCheckpoint{}
+		if err := rows.Scan(&checkpoint.ID, &checkpoint.EventID, &checkpoint.Hash, &checkpoint.Signature, &checkpoint.CreatedAt); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+const eventColumns = `id, action, COALESCE(actor_id, 0), COALESCE(target_id, 0), ip, user_agent, details, created_at, prev_hash, hash, salts, redactions`
+
+func (a *auditRepository) queryEvents(ctx context.Context, query string, args ...interface{}) ([]domain.AuditEvent, error) {
+
+	rows, err := a.Conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.AuditEvent{}
+	for rows.Next() {
+		event := domain.AuditEvent{}
+		if err := rows.Scan(&event.ID, &event.Action, &event.ActorID, &event.TargetID, &event.IP, &event.UserAgent,
+			&event.Details, &event.CreatedAt, &event.PrevHash, &event.Hash, &event.Salts, &event.Redactions); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func filterClause(filter domain.AuditFilter) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Action != "" {
+		add("action=$%d", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		add("actor_id=$%d", filter.ActorID)
+	}
+	if filter.TargetID != 0 {
+		add("target_id=$%d", filter.TargetID)
+	}
+	if filter.SubjectID != 0 {
+		add("$%[1]d IN (actor_id, target_id)", filter.SubjectID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at>=$%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at<$%d", filter.To)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func nullID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
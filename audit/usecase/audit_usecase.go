@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"time"
+	"transaction-service/domain"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type auditUsecase struct {
+	auditRepo      domain.AuditRepository
+	signingKey     []byte
+	timeoutContext time.Duration
+}
+
+func NewAuditUsecase(repo domain.AuditRepository, signingKey []byte, time time.Duration) domain.AuditUsecase {
+	return &auditUsecase{auditRepo: repo, signingKey: signingKey, timeoutContext: time}
+}
+
+func (a *auditUsecase) RecordEvent(ctx context.Context, event *domain.AuditEvent) error {
+	context, cancel := context.WithTimeout(ctx, a.timeoutContext)
+	defer cancel()
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	// postgres keeps microseconds, the chain hash must survive the round trip
+	event.CreatedAt = event.CreatedAt.Truncate(time.Microsecond)
+	if err := a.auditRepo.InsertEvent(context, event); err != nil {
+		return domain.Internal("cannot record audit event "+event.Action, err)
+	}
+	return nil
+}
+
+func (a *auditUsecase) ListEvents(ctx context.Context, filter domain.AuditFilter) (*domain.AuditPage, error) {
+	context, cancel := context.WithTimeout(ctx, a.timeoutContext)
+	defer cancel()
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	events, total, err := a.auditRepo.ListEvents(context, filter)
+	if err != nil {
+		return nil, domain.Internal("cannot list audit events", err)
+	}
+	return &domain.AuditPage{Events: events, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
@@ -0,0 +1,79 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/audit/usecase"
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+)
+
+func TestRecordEvent(t *testing.T) {
+	mockAuditRepo := new(mocks.AuditRepository)
+	event := &domain.AuditEvent{
+		Action:  domain.AuditLoginSuccess,
+		ActorID: 25,
+		IP:      "127.0.0.1",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockAuditRepo.On("InsertEvent", mock.Anything, event).Return(nil).Once()
+
+		u := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second)
+		err := u.RecordEvent(context.Background(), event)
+
+		assert.NoError(t, err)
+		assert.False(t, event.CreatedAt.IsZero())
+
+		mockAuditRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockAuditRepo.On("InsertEvent", mock.Anything, event).Return(errors.New("Unexpected")).Once()
+
+		u := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second)
+		err := u.RecordEvent(context.Background(), event)
+
+		assert.Error(t, err)
+
+		mockAuditRepo.AssertExpectations(t)
+	})
+}
+
+func TestListEvents(t *testing.T) {
+	mockAuditRepo := new(mocks.AuditRepository)
+	events := []domain.AuditEvent{
+		{ID: 2, Action: domain.AuditLoginFailure},
+		{ID: 1, Action: domain.AuditLoginSuccess},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockAuditRepo.On("ListEvents", mock.Anything, domain.AuditFilter{Action: "login.success", Limit: 200}).Return(events, int64(2), nil).Once()
+
+		u := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second)
+		page, err := u.ListEvents(context.Background(), domain.AuditFilter{Action: "login.success", Limit: 1000, Offset: -5})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), page.Total)
+		assert.Equal(t, 200, page.Limit)
+		assert.Len(t, page.Events, 2)
+
+		mockAuditRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockAuditRepo.On("ListEvents", mock.Anything, domain.AuditFilter{Limit: 50}).Return(nil, int64(0), errors.New("Unexpected")).Once()
+
+		u := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second)
+		page, err := u.ListEvents(context.Background(), domain.AuditFilter{})
+
+		assert.Error(t, err)
+		assert.Nil(t, page)
+
+		mockAuditRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+	"transaction-service/domain"
+)
+
+const chainBatchSize = 500
+
+// CreateCheckpoint signs the current chain head. It returns nil when nothing
+// was recorded since the previous checkpoint.
+func (a *auditUsecase) CreateCheckpoint(ctx context.Context) (*domain.AuditCheckpoint, error) {
+	context, cancel := context.WithTimeout(ctx, a.timeoutContext)
+	defer cancel()
+
+	head, err := a.auditRepo.LastEvent(context)
+	if err != nil {
+		return nil, domain.Internal("cannot read audit chain head", err)
+	}
+	if head == nil || head.Hash == "" {
+		return nil, nil
+	}
+	last, err := a.auditRepo.LastCheckpoint(context)
+	if err != nil {
+		return nil, domain.Internal("cannot read last audit checkpoint", err)
+	}
+	if last != nil && last.EventID == head.ID {
+		return nil, nil
+	}
+
+	checkpoint := &domain.AuditCheckpoint{
+		EventID:   head.ID,
+		Hash:      head.Hash,
+		Signature: a.sign(head.ID, head.Hash),
+		CreatedAt: time.Now(),
+	}
+	if err := a.auditRepo.InsertCheckpoint(context, checkpoint); err != nil {
+		return nil, domain.Internal("cannot create audit checkpoint", err)
+	}
+	return checkpoint, nil
+}
+
+// VerifyChain walks the whole audit log in order, recomputing every hash and
+// checking every checkpoint signature, and reports the first broken link.
+func (a *auditUsecase) VerifyChain(ctx context.Context) (*domain.AuditVerification, error) {
+	checkpoints, err := a.listCheckpoints(ctx)
+	if err != nil {
+		return nil, domain.Internal("cannot list audit checkpoints", err)
+	}
+
+	result := &domain.AuditVerification{Checkpoints: len(checkpoints)}
+	pending := map[int64][]domain.AuditCheckpoint{}
+	for _, checkpoint := range checkpoints {
+		if !hmac.Equal([]byte(a.sign(checkpoint.EventID, checkpoint.Hash)), []byte(checkpoint.Signature)) {
+			result.BrokenEventID = checkpoint.EventID
+			result.Reason = fmt.Sprintf("checkpoint %d has an invalid signature", checkpoint.ID)
+			return result, nil
+		}
+		pending[checkpoint.EventID] = append(pending[checkpoint.EventID], checkpoint)
+	}
+
+	prevHash := ""
+	chained := false
+	var afterID int64
+	for {
+		batch, err := a.listChain(ctx, afterID)
+		if err != nil {
+			return nil, domain.Internal("cannot read audit chain", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, event := range batch {
+			afterID = event.ID
+			// records written before chaining was introduced
+			if event.Hash == "" && !chained {
+				result.Legacy++
+				continue
+			}
+			chained = true
+
+			switch {
+			case event.PrevHash != prevHash:
+				result.Reason = "previous hash does not match the preceding record"
+			case event.ComputeHash(prevHash) != event.Hash:
+				result.Reason = "record content does not match its hash"
+			}
+			for _, checkpoint := range pending[event.ID] {
+				if result.Reason == "" && checkpoint.Hash != event.Hash {
+					result.Reason = fmt.Sprintf("record does not match checkpoint %d", checkpoint.ID)
+				}
+			}
+			if result.Reason != "" {
+				result.BrokenEventID = event.ID
+				return result, nil
+			}
+			delete(pending, event.ID)
+			prevHash = event.Hash
+			result.Checked++
+			if len(event.Redactions) > 0 {
+				result.Redacted++
+			}
+		}
+	}
+
+	if len(pending) > 0 {
+		missing := make([]int64, 0, len(pending))
+		for id := range pending {
+			missing = append(missing, id)
+		}
+		sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+		result.BrokenEventID = missing[0]
+		result.Reason = "checkpointed record is missing"
+	}
+	return result, nil
+}
+
+func (a *auditUsecase) listCheckpoints(ctx context.Context) ([]domain.AuditCheckpoint, error) {
+	context, cancel := context.WithTimeout(ctx, a.timeoutContext)
+	defer cancel()
+	return a.auditRepo.ListCheckpoints(context)
+}
+
+func (a *auditUsecase) listChain(ctx context.Context, afterID int64) ([]domain.AuditEvent, error) {
+	context, cancel := context.WithTimeout(ctx, a.timeoutContext)
+	defer cancel()
+	return a.auditRepo.ListChain(context, afterID, chainBatchSize)
+}
+
+func (a *auditUsecase) sign(eventID int64, hash string) string {
+	mac := hmac.New(sha256.New, a.signingKey)
+	fmt.Fprintf(mac, "%d:%s", eventID, hash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,159 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/audit/usecase"
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+)
+
+func chain(n int) []domain.AuditEvent {
+	events := []domain.AuditEvent{}
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		event := domain.AuditEvent{
+			ID:        int64(i),
+			Action:    domain.AuditLoginSuccess,
+			ActorID:   int64(i),
+			IP:        "127.0.0.1",
+			CreatedAt: time.Now().Truncate(time.Microsecond),
+			PrevHash:  prevHash,
+		}
+		if err := event.NewSalts(); err != nil {
+			panic(err)
+		}
+		event.Hash = event.ComputeHash(prevHash)
+		prevHash = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestCreateCheckpoint(t *testing.T) {
+	mockAuditRepo := new(mocks.AuditRepository)
+	events := chain(3)
+
+	t.Run("success", func(t *testing.T) {
+		mockAuditRepo.On("LastEvent", mock.Anything).Return(&events[2], nil).Once()
+		mockAuditRepo.On("LastCheckpoint", mock.Anything).Return(nil, nil).Once()
+		mockAuditRepo.On("InsertCheckpoint", mock.Anything, mock.AnythingOfType("*domain.AuditCheckpoint")).Return(nil).Once()
+
+		u := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second)
+		checkpoint, err := u.CreateCheckpoint(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, events[2].Hash, checkpoint.Hash)
+		assert.NotEmpty(t, checkpoint.Signature)
+
+		mockAuditRepo.AssertExpectations(t)
+	})
+	t.Run("unchanged", func(t *testing.T) {
+		mockAuditRepo.On("LastEvent", mock.Anything).Return(&events[2], nil).Once()
+		mockAuditRepo.On("LastCheckpoint", mock.Anything).Return(&domain.AuditCheckpoint{EventID: 3}, nil).Once()
+
+		u := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second)
+		checkpoint, err := u.CreateCheckpoint(context.Background())
+
+		assert.NoError(t, err)
+		assert.Nil(t, checkpoint)
+
+		mockAuditRepo.AssertExpectations(t)
+	})
+}
+
+func TestVerifyChain(t *testing.T) {
+	signed := func(events []domain.AuditEvent, at int) []domain.AuditCheckpoint {
+		mockAuditRepo := new(mocks.AuditRepository)
+		mockAuditRepo.On("LastEvent", mock.Anything).Return(&events[at], nil)
+		mockAuditRepo.On("LastCheckpoint", mock.Anything).Return(nil, nil)
+		var checkpoint *domain.AuditCheckpoint
+		mockAuditRepo.On("InsertCheckpoint", mock.Anything, mock.AnythingOfType("*domain.AuditCheckpoint")).
+			Run(func(args mock.Arguments) { checkpoint = args.Get(1).(*domain.AuditCheckpoint) }).Return(nil)
+		_, err := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second).CreateCheckpoint(context.Background())
+		assert.NoError(t, err)
+		return []domain.AuditCheckpoint{*checkpoint}
+	}
+	verify := func(events []domain.AuditEvent, checkpoints []domain.AuditCheckpoint) *domain.AuditVerification {
+		mockAuditRepo := new(mocks.AuditRepository)
+		mockAuditRepo.On("ListCheckpoints", mock.Anything).Return(checkpoints, nil).Once()
+		mockAuditRepo.On("ListChain", mock.Anything, int64(0), mock.Anything).Return(events, nil).Once()
+		mockAuditRepo.On("ListChain", mock.Anything, events[len(events)-1].ID, mock.Anything).Return([]domain.AuditEvent{}, nil).Once()
+
+		result, err := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second).VerifyChain(context.Background())
+		assert.NoError(t, err)
+		return result
+	}
+
+	t.Run("success", func(t *testing.T) {
+		events := chain(4)
+		legacy := domain.AuditEvent{ID: 0, Action: domain.AuditRegistration}
+		result := verify(append([]domain.AuditEvent{legacy}, events...), signed(events, 2))
+
+		assert.True(t, result.OK())
+		assert.Equal(t, int64(4), result.Checked)
+		assert.Equal(t, int64(1), result.Legacy)
+	})
+	t.Run("modified-record", func(t *testing.T) {
+		events := chain(4)
+		events[1].Details = "tampered"
+		result := verify(events, nil)
+
+		assert.False(t, result.OK())
+		assert.Equal(t, int64(2), result.BrokenEventID)
+	})
+	t.Run("redacted-record", func(t *testing.T) {
+		events := chain(4)
+		checkpoints := signed(events, 3)
+		events[1].Redact("details")
+		events[1].Redact("ip")
+		result := verify(events, checkpoints)
+
+		assert.True(t, result.OK())
+		assert.Equal(t, int64(4), result.Checked)
+		assert.Equal(t, int64(1), result.Redacted)
+	})
+	t.Run("modified-redacted-record", func(t *testing.T) {
+		events := chain(4)
+		events[1].Redact("details")
+		events[1].Redactions["details"] = strings.Repeat("0", 64)
+		result := verify(events, nil)
+
+		assert.False(t, result.OK())
+		assert.Equal(t, int64(2), result.BrokenEventID)
+
+		events = chain(4)
+		events[1].Redact("ip")
+		events[1].IP = "10.0.0.1"
+		result = verify(events, nil)
+
+		assert.False(t, result.OK())
+		assert.Equal(t, int64(2), result.BrokenEventID)
+	})
+	t.Run("deleted-record", func(t *testing.T) {
+		events := chain(4)
+		result := verify(append(events[:1], events[2:]...), nil)
+
+		assert.False(t, result.OK())
+		assert.Equal(t, int64(3), result.BrokenEventID)
+	})
+	t.Run("forged-checkpoint", func(t *testing.T) {
+		events := chain(4)
+		checkpoints := signed(events, 3)
+		checkpoints[0].Signature = "00"
+
+		mockAuditRepo := new(mocks.AuditRepository)
+		mockAuditRepo.On("ListCheckpoints", mock.Anything).Return(checkpoints, nil).Once()
+		result, err := usecase.NewAuditUsecase(mockAuditRepo, []byte("secret"), 2*time.Second).VerifyChain(context.Background())
+
+		assert.NoError(t, err)
+		assert.False(t, result.OK())
+		assert.Equal(t, int64(4), result.BrokenEventID)
+	})
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"transaction-service/domain"
+	utils "transaction-service/utils"
+)
+
+func (a *app) createUser(ctx context.Context, args []string) error {
+	flags := newFlags("create-user")
+	username := flags.String("username", "", "username")
+	iin := flags.String("iin", "", "individual identification number")
+	role := flags.String("role", "user", "role of the new user")
+	password := flags.String("password", "", "password, generated when empty")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 || *username == "" || *iin == "" {
+		return fmt.Errorf("%w: create-user needs -username and -iin", errUsage)
+	}
+	if !domain.ValidRole(*role) {
+		return fmt.Errorf("%w: unknown role %q", errUsage, *role)
+	}
+
+	pass, generated, err := passwordOrGenerate(*password)
+	if err != nil {
+		return err
+	}
+	user := &domain.User{Username: *username, IIN: *iin, Password: pass}
+	if err := a.users.CreateUserWithRoleUsecase(ctx, user, *role); err != nil {
+		return err
+	}
+
+	a.record(ctx, domain.AuditRegistration, user.ID, "created with role "+user.Role)
+	fmt.Printf("created user %q with id %d and role %s\n", user.Username, user.ID, user.Role)
+	printPassword(generated, pass)
+	return nil
+}
+
+func (a *app) resetPassword(ctx context.Context, args []string) error {
+	flags := newFlags("reset-password")
+	password := flags.String("password", "", "new password, generated when empty")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return fmt.Errorf("%w: reset-password needs a username", errUsage)
+	}
+
+	pass, generated, err := passwordOrGenerate(*password)
+	if err != nil {
+		return err
+	}
+	user, err := a.users.ResetPasswordUsecase(ctx, flags.Arg(0), pass)
+	if err != nil {
+		return err
+	}
+	// a reset usually means the old password leaked
+	if err := a.jwt.RevokeToken(ctx, user.ID); err != nil {
+		return err
+	}
+
+	a.record(ctx, domain.AuditPasswordReset, user.ID, "sessions revoked")
+	fmt.Printf("password of %q reset, sessions revoked\n", user.Username)
+	printPassword(generated, pass)
+	return nil
+}
+
+func (a *app) setRole(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: set-role needs a username and a role", errUsage)
+	}
+
+	user, err := a.users.SetRoleUsecase(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	a.record(ctx, domain.AuditRoleChange, user.ID, "role set to "+user.Role)
+	// tokens carry the role, the old one must not outlive the change
+	if err := a.jwt.RevokeToken(ctx, user.ID); err != nil {
+		return err
+	}
+
+	a.record(ctx, domain.AuditTokenRevoked, user.ID, "role changed")
+	fmt.Printf("role of %q set to %s, sessions revoked\n", user.Username, user.Role)
+	return nil
+}
+
+func (a *app) setStatus(ctx context.Context, args []string) error {
+	flags := newFlags("set-status")
+	reason := flags.String("reason", "", "why the status changes, required unless the account becomes active")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return fmt.Errorf("%w: set-status needs a username and a status", errUsage)
+	}
+
+	user, err := a.users.ChangeStatusUsecase(ctx, 0, flags.Arg(0), flags.Arg(1), *reason)
+	if err != nil {
+		return err
+	}
+	a.record(ctx, domain.AuditStatusChange, user.ID, strings.TrimSpace("status set to "+user.Status+" "+*reason))
+	if user.Status == domain.StatusActive {
+		fmt.Printf("user %q is now %s\n", user.Username, user.Status)
+		return nil
+	}
+	if err := a.jwt.RevokeToken(ctx, user.ID); err != nil {
+		return err
+	}
+
+	a.record(ctx, domain.AuditTokenRevoked, user.ID, "account "+user.Status)
+	fmt.Printf("user %q is now %s, sessions revoked\n", user.Username, user.Status)
+	return nil
+}
+
+func (a *app) deleteUser(ctx context.Context, args []string) error {
+	flags := newFlags("delete-user")
+	reason := flags.String("reason", "", "why the account is deleted")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *reason == "" {
+		return fmt.Errorf("%w: delete-user needs -reason and a username", errUsage)
+	}
+
+	user, err := a.users.DeleteUserUsecase(ctx, 0, flags.Arg(0), *reason)
+	if err != nil {
+		return err
+	}
+	a.record(ctx, domain.AuditUserDelete, user.ID, *reason)
+	if err := a.jwt.RevokeToken(ctx, user.ID); err != nil {
+		return err
+	}
+
+	a.record(ctx, domain.AuditTokenRevoked, user.ID, "account deleted")
+	fmt.Printf("user %q deleted, sessions revoked, the data is erased after the grace period\n", user.Username)
+	return nil
+}
+
+// eraseDeleted runs the erasure job once, for one batch.
+func (a *app) eraseDeleted(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: erase-deleted takes no arguments", errUsage)
+	}
+
+	erased, err := a.erasure.EraseDeleted(ctx, time.Now())
+	for _, id := range erased {
+		a.record(ctx, domain.AuditUserErase, id, "personal data erased")
+	}
+	fmt.Printf("%d deleted accounts erased\n", len(erased))
+	return err
+}
+
+func (a *app) listSessions(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: list-sessions takes no arguments", errUsage)
+	}
+
+	sessions, err := a.jwt.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+	printSessions(os.Stdout, sessions)
+	return nil
+}
+
+func (a *app) revokeToken(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: revoke-token needs a user id", errUsage)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid user id %q", errUsage, args[0])
+	}
+
+	if err := a.jwt.RevokeToken(ctx, id); err != nil {
+		return err
+	}
+
+	a.record(ctx, domain.AuditTokenRevoked, id, "")
+	fmt.Printf("sessions of user %d revoked\n", id)
+	return nil
+}
+
+func (a *app) rotateKeys(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: rotate-keys takes no arguments", errUsage)
+	}
+
+	key, err := a.jwt.RotateSigningKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.record(ctx, domain.AuditKeyRotation, 0, "active key "+key.ID)
+	fmt.Printf("signing key %s is now active, running servers pick it up on their next key refresh\n", key.ID)
+	return nil
+}
+
+// reencryptIIN moves every IIN to the active master key in batches, so rows
+// are only locked briefly. The IINs stored before the encryption are sealed
+// too, and the signing secrets are moved along so the old master keys can go.
+func (a *app) reencryptIIN(ctx context.Context, args []string) error {
+	flags := newFlags("reencrypt-iin")
+	batch := flags.Int("batch", 100, "users updated per transaction")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 || *batch < 1 {
+		return fmt.Errorf("%w: reencrypt-iin takes a positive -batch only", errUsage)
+	}
+
+	total := 0
+	for {
+		n, err := a.iins.Reencrypt(ctx, *batch)
+		total += n
+		if err != nil {
+			fmt.Printf("%d IINs moved before the failure\n", total)
+			return err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	keys, err := a.signing.Reencrypt(ctx)
+	if err != nil {
+		fmt.Printf("%d IINs moved, the signing keys were not\n", total)
+		return err
+	}
+	a.record(ctx, domain.AuditIINReencrypt, 0, fmt.Sprintf("%d IINs and %d signing keys moved to master key %s", total, keys, a.keys.ActiveID()))
+	fmt.Printf("%d IINs and %d signing keys moved, every one now uses master key %s and the other master keys can be removed\n", total, keys, a.keys.ActiveID())
+	return nil
+}
+
+// record adds an audit event for the command, failures are reported but do not fail the command.
+func (a *app) record(ctx context.Context, action string, targetID int64, details string) {
+	event := &domain.AuditEvent{Action: action, TargetID: targetID, UserAgent: "authctl", Details: details}
+	if err := a.audit.RecordEvent(ctx, event); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+func newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+func passwordOrGenerate(password string) (string, bool, error) {
+	if password != "" {
+		return password, false, nil
+	}
+	generated, err := utils.GeneratePassword(16)
+	if err != nil {
+		return "", false, fmt.Errorf("generate password: %w", err)
+	}
+	return generated, true, nil
+}
+
+func printPassword(generated bool, password string) {
+	if generated {
+		fmt.Printf("generated password: %s\nit will not be shown again\n", password)
+	}
+}
+
+func printSessions(out io.Writer, sessions []domain.Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].UserID != sessions[j].UserID {
+			return sessions[i].UserID < sessions[j].UserID
+		}
+		return sessions[i].ActorID < sessions[j].ActorID
+	})
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tIMPERSONATED BY\tEXPIRES IN")
+	for _, session := range sessions {
+		user, actor := strconv.FormatInt(session.UserID, 10), "-"
+		if session.ActorID != 0 {
+			user, actor = "?", strconv.FormatInt(session.ActorID, 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", user, actor, session.TTL)
+	}
+	w.Flush()
+}
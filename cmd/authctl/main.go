@@ -0,0 +1,174 @@
+// Command authctl manages users, sessions and signing keys directly against
+// postgres and redis, using the same configuration as the server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	_auditRepo "transaction-service/audit/repository/postgres"
+	_auditUsecase "transaction-service/audit/usecase"
+	"transaction-service/config"
+	"transaction-service/connection"
+	"transaction-service/domain"
+	"transaction-service/envelope"
+	"transaction-service/migrations"
+	_repo "transaction-service/users/repository/postgres"
+	_redis "transaction-service/users/repository/redis"
+	_usecase "transaction-service/users/usecase"
+
+	"github.com/go-redis/redis"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+var usage = `usage: authctl [-config file] <command> [arguments]
+
+commands:
+  authctl create-user -username name -iin iin [-role role] [-password password]
+  authctl reset-password [-password password] username
+  authctl set-role username role
+  authctl set-status [-reason reason] username pending|active|suspended|closed
+  authctl delete-user -reason reason username
+  authctl erase-deleted
+  authctl list-sessions
+  authctl revoke-token user-id
+  authctl rotate-keys
+  authctl reencrypt-iin [-batch size]
+` + migrations.Usage("authctl") + `
+A password is generated and printed when none is given.
+`
+
+// errUsage makes the command exit with status 2 and print the usage.
+var errUsage = errors.New("invalid arguments")
+
+type app struct {
+	db    *pgxpool.Pool
+	redis *redis.Client
+
+	users   domain.UserUsecase
+	jwt     domain.JwtTokenUsecase
+	audit   domain.AuditUsecase
+	erasure domain.ErasureUsecase
+	iins    *_repo.IINReencryptor
+	signing *_repo.SigningKeyReencryptor
+	keys    *envelope.Keyring
+}
+
+func main() {
+	flags := flag.NewFlagSet("authctl", flag.ExitOnError)
+	configFile := flags.String("config", "", "path to the configuration file")
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer a.close()
+
+	err = a.run(context.Background(), flags.Arg(0), flags.Args()[1:])
+	switch {
+	case errors.Is(err, errUsage) || errors.Is(err, migrations.ErrUsage):
+		fmt.Fprintf(os.Stderr, "%v\n\n%s", err, usage)
+		a.close()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "authctl %s: %v\n", flags.Arg(0), err)
+		a.close()
+		os.Exit(1)
+	}
+}
+
+func newApp(cfg *config.Config) (*app, error) {
+	db, err := connection.Postgres(cfg.Postgres)
+	if err != nil {
+		return nil, err
+	}
+	client, err := connection.Redis(cfg.Redis)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	token := cfg.JwtToken()
+	timeout := cfg.Timeout
+	policy, err := cfg.Password.Policy()
+	if err != nil {
+		db.Close()
+		client.Close()
+		return nil, err
+	}
+
+	keys, err := cfg.Encryption.Keyring()
+	if err != nil {
+		db.Close()
+		client.Close()
+		return nil, err
+	}
+
+	userRepo := _repo.NewUserRepository(db, keys)
+	jwt := _usecase.NewJWTUseCase(token, _redis.NewRedisRepo(client), _repo.NewSigningKeyRepository(db, keys))
+	return &app{
+		db:      db,
+		redis:   client,
+		users:   _usecase.NewUserUseCase(userRepo, timeout, policy, cfg.Registration.MinAge),
+		jwt:     jwt,
+		audit:   _auditUsecase.NewAuditUsecase(_auditRepo.NewAuditRepository(db), []byte(token.AccessSecret), timeout),
+		erasure: _usecase.NewErasureUsecase(userRepo, jwt, cfg.Erasure.GracePeriod, cfg.Erasure.BatchSize, timeout),
+		iins:    _repo.NewIINReencryptor(db, keys),
+		signing: _repo.NewSigningKeyReencryptor(db, keys),
+		keys:    keys,
+	}, nil
+}
+
+func (a *app) close() {
+	a.redis.Close()
+	a.db.Close()
+}
+
+func (a *app) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "create-user":
+		return a.createUser(ctx, args)
+	case "reset-password":
+		return a.resetPassword(ctx, args)
+	case "set-role":
+		return a.setRole(ctx, args)
+	case "set-status":
+		return a.setStatus(ctx, args)
+	case "delete-user":
+		return a.deleteUser(ctx, args)
+	case "erase-deleted":
+		return a.eraseDeleted(ctx, args)
+	case "list-sessions":
+		return a.listSessions(ctx, args)
+	case "revoke-token":
+		return a.revokeToken(ctx, args)
+	case "rotate-keys":
+		return a.rotateKeys(ctx, args)
+	case "reencrypt-iin":
+		return a.reencryptIIN(ctx, args)
+	case "migrate":
+		migrator, err := migrations.NewMigrator(a.db)
+		if err != nil {
+			return err
+		}
+		return migrations.RunCommand(ctx, migrator, args, os.Stdout)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
@@ -0,0 +1,703 @@
+// Package config loads the service configuration from a JSON file, environment
+// variables and secret files, in increasing order of precedence.
+//
+// Every key can be overridden with an AUTH_ prefixed variable, nested keys are
+// joined by underscores: postgres.host becomes AUTH_POSTGRES_HOST. Setting
+// AUTH_POSTGRES_PASSWORD_FILE instead reads the value from that file, which is
+// how docker and kubernetes secrets are mounted. Lists are comma separated.
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"transaction-service/domain"
+	"transaction-service/envelope"
+	"transaction-service/password"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+const envPrefix = "AUTH"
+
+// DefaultPath is used when neither the -config flag nor AUTH_CONFIG is set.
+const DefaultPath = "config.json"
+
+type Config struct {
+	Addr    string
+	Timeout time.Duration
+
+	Postgres Postgres
+	Redis    Redis
+	Admin    Admin
+	Audit    Audit
+	Token    Token
+	Shutdown Shutdown
+	Health   Health
+	Tracing  Tracing
+	Password Password
+
+	Registration Registration
+	Erasure      Erasure
+	Encryption   Encryption
+}
+
+type Postgres struct {
+	User     string
+	Password string
+	Host     string
+	Port     int
+	DBName   string
+}
+
+func (p Postgres) DSN() string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(p.User, p.Password),
+		Host:   net.JoinHostPort(p.Host, strconv.Itoa(p.Port)),
+		Path:   "/" + p.DBName,
+	}
+	return dsn.String()
+}
+
+type Redis struct {
+	Address  string
+	Password string
+}
+
+// Admin is the account created on first start when no admin exists.
+type Admin struct {
+	Username string
+	// IIN has no default, like the admin password it is set through the
+	// environment (AUTH_ADMIN_IIN) and required only to create the admin.
+	IIN string
+}
+
+type Registration struct {
+	// MinAge is the youngest age, derived from the IIN, allowed to sign up.
+	MinAge int
+}
+
+// Erasure is the removal of the personal data of deleted accounts.
+type Erasure struct {
+	// GracePeriod is how long a deleted account is kept before it is erased.
+	GracePeriod time.Duration
+	// Interval is the time between two erasure runs, 0 disables the job.
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Encryption holds the keys encrypting personal data and signing secrets at
+// rest, given base64 encoded. They have no default and are set through the
+// environment or secret files, never in the config file baked into the image.
+type Encryption struct {
+	// MasterKeys are 32 byte keys by ID, set as an object in the config file or
+	// as AUTH_ENCRYPTION_MASTER_KEYS=k1=key,k2=key.
+	MasterKeys map[string]string
+	// ActiveKey is the master key new data keys are wrapped with, the others
+	// are kept until authctl reencrypt-iin has moved the data off them.
+	ActiveKey string
+	// IndexKey is the blind index key, changing it breaks every lookup.
+	IndexKey string
+}
+
+// Keyring decodes the keys.
+func (e Encryption) Keyring() (*envelope.Keyring, error) {
+	masters := map[string][]byte{}
+	for id, key := range e.MasterKeys {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("decode master key %q: %w", id, err)
+		}
+		masters[id] = decoded
+	}
+	index, err := base64.StdEncoding.DecodeString(e.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode index key: %w", err)
+	}
+	return envelope.NewKeyring(masters, e.ActiveKey, index)
+}
+
+type Audit struct {
+	CheckpointInterval time.Duration
+}
+
+type Shutdown struct {
+	// DrainDelay keeps serving after readiness turns false so load balancers
+	// can take the instance out of rotation before the listener closes.
+	DrainDelay time.Duration
+	// Timeout bounds draining in-flight requests and closing connections.
+	Timeout time.Duration
+}
+
+type Health struct {
+	// CacheTTL is how long a readiness report is reused.
+	CacheTTL time.Duration
+	// Timeout bounds every dependency check.
+	Timeout time.Duration
+	// MaxBackoff caps the wait between connection attempts at startup.
+	MaxBackoff time.Duration
+	// TransactionServiceURL is checked when set, its failure only degrades readiness.
+	TransactionServiceURL string
+}
+
+type Tracing struct {
+	// Exporter is none, stdout or otlp.
+	Exporter string
+	// Endpoint is the host:port of the OTLP HTTP collector, OTEL_EXPORTER_OTLP_ENDPOINT is used when empty.
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	// SampleRatio is the share of new traces recorded, between 0 and 1.
+	SampleRatio float64
+}
+
+// Password is the policy new passwords must follow.
+type Password struct {
+	MinLength      int
+	MaxLength      int
+	RequireLower   bool
+	RequireUpper   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	AllowUnicode   bool
+	RejectPersonal bool
+	// MinScore is the lowest accepted strength, from 0 (any) to 4.
+	MinScore int
+	// BreachedList is a file of common or leaked passwords, one per line.
+	BreachedList string
+	// History is how many previous passwords cannot be reused.
+	History int
+	// MaxAge is how long passwords of a role stay valid, set as days by role
+	// name or as AUTH_PASSWORD_MAX_AGE=admin=90,support=180.
+	MaxAge map[string]time.Duration
+}
+
+// Policy builds the password policy, loading the breached list when one is set.
+func (p Password) Policy() (*password.Policy, error) {
+	policy := &password.Policy{
+		MinLength:      p.MinLength,
+		MaxLength:      p.MaxLength,
+		RequireLower:   p.RequireLower,
+		RequireUpper:   p.RequireUpper,
+		RequireDigit:   p.RequireDigit,
+		RequireSpecial: p.RequireSpecial,
+		AllowUnicode:   p.AllowUnicode,
+		RejectPersonal: p.RejectPersonal,
+		MinScore:       p.MinScore,
+		History:        p.History,
+		MaxAge:         p.MaxAge,
+	}
+	if p.BreachedList != "" {
+		breached, err := password.LoadBloom(p.BreachedList)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = breached
+	}
+	return policy, nil
+}
+
+// sampleTokenSecret is the token secret the sample config once shipped with.
+const sampleTokenSecret = "super secret code"
+
+type Token struct {
+	// Secret has no default and is set through the environment or a secret
+	// file, never in the config file baked into the image.
+	Secret           string
+	TTL              time.Duration
+	ImpersonationTTL time.Duration
+	// KeyRefresh is how often rotated signing keys are reloaded.
+	KeyRefresh time.Duration
+
+	ExchangeTTL time.Duration
+	// ExchangeClients are the secrets of the services allowed to exchange
+	// tokens by client ID, set as AUTH_TOKEN_EXCHANGE_CLIENTS=id=secret,...
+	// rather than in the config file.
+	ExchangeClients map[string]string
+	ExchangeScopes  []string
+}
+
+func (c *Config) JwtToken() domain.JwtToken {
+	return domain.JwtToken{
+		AccessSecret:     c.Token.Secret,
+		AccessTtl:        c.Token.TTL,
+		ImpersonationTtl: c.Token.ImpersonationTTL,
+		ExchangeTtl:      c.Token.ExchangeTTL,
+		ExchangeClients:  c.Token.ExchangeClients,
+		ExchangeScopes:   c.Token.ExchangeScopes,
+	}
+}
+
+// defaults lists every key with its default, keys without a sensible default are empty.
+var defaults = map[string]interface{}{
+	"addr":    ":8080",
+	"timeout": 2,
+
+	"postgres.user":     "",
+	"postgres.password": "",
+	"postgres.host":     "",
+	"postgres.port":     5432,
+	"postgres.dbname":   "",
+
+	"redis.address":  "",
+	"redis.password": "",
+
+	"admin.username": "admin",
+	"admin.iin":      "",
+
+	"audit.checkpoint_interval": 60,
+
+	"registration.min_age": 18,
+
+	"erasure.grace_period": 30,
+	"erasure.interval":     60,
+	"erasure.batch_size":   100,
+
+	"shutdown.drain_delay": 5,
+	"shutdown.timeout":     15,
+
+	"health.cache_ttl":               2,
+	"health.timeout":                 1,
+	"health.max_backoff":             30,
+	"health.transaction_service_url": "",
+
+	"tracing.exporter":     "none",
+	"tracing.endpoint":     "",
+	"tracing.insecure":     false,
+	"tracing.service_name": "authorization-service",
+	"tracing.sample_ratio": 1.0,
+
+	"password.min_length":      6,
+	"password.max_length":      128,
+	"password.require_lower":   true,
+	"password.require_upper":   true,
+	"password.require_digit":   true,
+	"password.require_special": false,
+	"password.allow_unicode":   true,
+	"password.reject_personal": true,
+	"password.min_score":       0,
+	"password.breached_list":   "",
+	"password.history":         5,
+	"password.max_age":         map[string]interface{}{},
+
+	"encryption.master_keys": map[string]interface{}{},
+	"encryption.active_key":  "",
+	"encryption.index_key":   "",
+
+	"token.secret":            "",
+	"token.ttl":               30,
+	"token.impersonation_ttl": 15,
+	"token.key_refresh":       60,
+	"token.exchange.ttl":      5,
+	"token.exchange.clients":  map[string]interface{}{},
+	"token.exchange.scopes":   []string{},
+}
+
+// aliases are extra environment variables read for a key.
+var aliases = map[string][]string{
+	"redis.address": {"REDIS_URL"},
+}
+
+// Load reads the configuration from path, or from AUTH_CONFIG or DefaultPath when
+// path is empty, and validates it. A missing default file is not an error so the
+// service can be configured by environment alone.
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+		v.BindEnv(append([]string{key, EnvName(key)}, aliases[key]...)...)
+	}
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(envPrefix + "_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = DefaultPath
+	}
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		if explicit || !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("read config %s: %w", path, err)
+		}
+	}
+
+	for key := range defaults {
+		file := os.Getenv(EnvName(key) + "_FILE")
+		if file == "" {
+			continue
+		}
+		secret, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s_FILE: %w", EnvName(key), err)
+		}
+		v.Set(key, strings.TrimSpace(string(secret)))
+	}
+
+	c, err := decode(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// EnvName returns the environment variable overriding key.
+func EnvName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func decode(v *viper.Viper) (*Config, error) {
+	d := decoder{v: v}
+	c := &Config{
+		Addr:    d.string("addr"),
+		Timeout: d.duration("timeout", time.Second),
+		Postgres: Postgres{
+			User:     d.string("postgres.user"),
+			Password: d.string("postgres.password"),
+			Host:     d.string("postgres.host"),
+			Port:     d.int("postgres.port"),
+			DBName:   d.string("postgres.dbname"),
+		},
+		Redis: Redis{
+			Address:  d.string("redis.address"),
+			Password: d.string("redis.password"),
+		},
+		Admin: Admin{
+			Username: d.string("admin.username"),
+			IIN:      d.string("admin.iin"),
+		},
+		Registration: Registration{
+			MinAge: d.int("registration.min_age"),
+		},
+		Erasure: Erasure{
+			GracePeriod: d.duration("erasure.grace_period", 24*time.Hour),
+			Interval:    d.duration("erasure.interval", time.Minute),
+			BatchSize:   d.int("erasure.batch_size"),
+		},
+		Encryption: Encryption{
+			MasterKeys: d.stringMap("encryption.master_keys"),
+			ActiveKey:  d.string("encryption.active_key"),
+			IndexKey:   d.string("encryption.index_key"),
+		},
+		Audit: Audit{
+			CheckpointInterval: d.duration("audit.checkpoint_interval", time.Minute),
+		},
+		Shutdown: Shutdown{
+			DrainDelay: d.duration("shutdown.drain_delay", time.Second),
+			Timeout:    d.duration("shutdown.timeout", time.Second),
+		},
+		Health: Health{
+			CacheTTL:              d.duration("health.cache_ttl", time.Second),
+			Timeout:               d.duration("health.timeout", time.Second),
+			MaxBackoff:            d.duration("health.max_backoff", time.Second),
+			TransactionServiceURL: d.string("health.transaction_service_url"),
+		},
+		Tracing: Tracing{
+			Exporter:    d.string("tracing.exporter"),
+			Endpoint:    d.string("tracing.endpoint"),
+			Insecure:    d.bool("tracing.insecure"),
+			ServiceName: d.string("tracing.service_name"),
+			SampleRatio: d.float("tracing.sample_ratio"),
+		},
+		Password: Password{
+			MinLength:      d.int("password.min_length"),
+			MaxLength:      d.int("password.max_length"),
+			RequireLower:   d.bool("password.require_lower"),
+			RequireUpper:   d.bool("password.require_upper"),
+			RequireDigit:   d.bool("password.require_digit"),
+			RequireSpecial: d.bool("password.require_special"),
+			AllowUnicode:   d.bool("password.allow_unicode"),
+			RejectPersonal: d.bool("password.reject_personal"),
+			MinScore:       d.int("password.min_score"),
+			BreachedList:   d.string("password.breached_list"),
+			History:        d.int("password.history"),
+			MaxAge:         d.durations("password.max_age", 24*time.Hour),
+		},
+		Token: Token{
+			Secret:           d.string("token.secret"),
+			TTL:              d.duration("token.ttl", time.Minute),
+			ImpersonationTTL: d.duration("token.impersonation_ttl", time.Minute),
+			KeyRefresh:       d.duration("token.key_refresh", time.Second),
+			ExchangeTTL:      d.duration("token.exchange.ttl", time.Minute),
+			ExchangeClients:  d.stringMap("token.exchange.clients"),
+			ExchangeScopes:   d.list("token.exchange.scopes"),
+		},
+	}
+	if len(d.errs) > 0 {
+		return nil, &ValidationError{Problems: d.errs}
+	}
+	return c, nil
+}
+
+// decoder converts raw values and collects every conversion problem.
+type decoder struct {
+	v    *viper.Viper
+	errs []string
+}
+
+func (d *decoder) string(key string) string {
+	return strings.TrimSpace(d.v.GetString(key))
+}
+
+func (d *decoder) int(key string) int {
+	n, err := cast.ToIntE(d.v.Get(key))
+	if err != nil {
+		d.errs = append(d.errs, fmt.Sprintf("%s (%s) must be a number, got %q", key, EnvName(key), d.v.GetString(key)))
+	}
+	return n
+}
+
+func (d *decoder) bool(key string) bool {
+	b, err := cast.ToBoolE(d.v.Get(key))
+	if err != nil {
+		d.errs = append(d.errs, fmt.Sprintf("%s (%s) must be true or false, got %q", key, EnvName(key), d.v.GetString(key)))
+	}
+	return b
+}
+
+func (d *decoder) float(key string) float64 {
+	f, err := cast.ToFloat64E(d.v.Get(key))
+	if err != nil {
+		d.errs = append(d.errs, fmt.Sprintf("%s (%s) must be a number, got %q", key, EnvName(key), d.v.GetString(key)))
+	}
+	return f
+}
+
+// duration reads a number of units, the unit config.json has always used for key.
+func (d *decoder) duration(key string, unit time.Duration) time.Duration {
+	return time.Duration(d.int(key)) * unit
+}
+
+// durations reads a map of numbers of units, given as an object in the config
+// file or as a comma separated list of name=number.
+func (d *decoder) durations(key string, unit time.Duration) map[string]time.Duration {
+	values, ok := d.pairs(key, "number")
+	if !ok {
+		return nil
+	}
+	durations := map[string]time.Duration{}
+	for name, value := range values {
+		n, err := cast.ToIntE(value)
+		if err != nil {
+			d.errs = append(d.errs, fmt.Sprintf("%s.%s (%s) must be a number, got %q", key, name, EnvName(key), cast.ToString(value)))
+			continue
+		}
+		durations[name] = time.Duration(n) * unit
+	}
+	return durations
+}
+
+// stringMap reads a map of strings, given as an object in the config file or as
+// a comma separated list of name=value.
+func (d *decoder) stringMap(key string) map[string]string {
+	values, ok := d.pairs(key, "value")
+	if !ok {
+		return nil
+	}
+	strs := map[string]string{}
+	for name, value := range values {
+		strs[name] = strings.TrimSpace(cast.ToString(value))
+	}
+	return strs
+}
+
+// pairs reads the raw map of key, what names the values in the messages.
+func (d *decoder) pairs(key, what string) (map[string]interface{}, bool) {
+	raw := d.v.Get(key)
+	if s, ok := raw.(string); ok {
+		pairs := map[string]interface{}{}
+		for _, pair := range strings.Split(s, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			name, value, ok := cut(pair, "=")
+			if !ok {
+				d.errs = append(d.errs, fmt.Sprintf("%s (%s) must be a list of name=%s, got %q", key, EnvName(key), what, s))
+				return nil, false
+			}
+			pairs[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		raw = pairs
+	}
+
+	values, err := cast.ToStringMapE(raw)
+	if err != nil {
+		d.errs = append(d.errs, fmt.Sprintf("%s (%s) must be a map of %ss, got %q", key, EnvName(key), what, d.v.GetString(key)))
+		return nil, false
+	}
+	return values, true
+}
+
+// cut is strings.Cut, which needs go 1.18.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func (d *decoder) list(key string) []string {
+	raw := d.v.Get(key)
+	if s, ok := raw.(string); ok {
+		raw = strings.Split(s, ",")
+	}
+	list := []string{}
+	for _, item := range cast.ToStringSlice(raw) {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// ValidationError lists every invalid or missing value at once.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+func (c *Config) Validate() error {
+	var problems []string
+	required := func(key, value string) {
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("%s is required, set it in the config file or %s", key, EnvName(key)))
+		}
+	}
+	positive := func(key string, value time.Duration) {
+		if value <= 0 {
+			problems = append(problems, fmt.Sprintf("%s (%s) must be positive", key, EnvName(key)))
+		}
+	}
+
+	required("addr", c.Addr)
+	required("postgres.user", c.Postgres.User)
+	required("postgres.host", c.Postgres.Host)
+	required("postgres.dbname", c.Postgres.DBName)
+	required("redis.address", c.Redis.Address)
+	required("token.secret", c.Token.Secret)
+	required("admin.username", c.Admin.Username)
+	required("encryption.active_key", c.Encryption.ActiveKey)
+	required("encryption.index_key", c.Encryption.IndexKey)
+
+	if c.Postgres.Port < 1 || c.Postgres.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("postgres.port (%s) must be between 1 and 65535, got %d", EnvName("postgres.port"), c.Postgres.Port))
+	}
+	if c.Token.Secret != "" && len(c.Token.Secret) < 16 {
+		problems = append(problems, fmt.Sprintf("token.secret (%s) must be at least 16 characters", EnvName("token.secret")))
+	}
+	if c.Token.Secret == sampleTokenSecret {
+		problems = append(problems, fmt.Sprintf("token.secret (%s) is the sample value, generate a new one", EnvName("token.secret")))
+	}
+	for id, secret := range c.Token.ExchangeClients {
+		if len(secret) < 16 {
+			problems = append(problems, fmt.Sprintf("token.exchange.clients.%s (%s) must be at least 16 characters", id, EnvName("token.exchange.clients")))
+		}
+	}
+	positive("timeout", c.Timeout)
+	positive("token.ttl", c.Token.TTL)
+	positive("token.impersonation_ttl", c.Token.ImpersonationTTL)
+	positive("token.exchange.ttl", c.Token.ExchangeTTL)
+	if c.Audit.CheckpointInterval < 0 {
+		problems = append(problems, fmt.Sprintf("audit.checkpoint_interval (%s) must not be negative, 0 disables checkpoints", EnvName("audit.checkpoint_interval")))
+	}
+	positive("shutdown.timeout", c.Shutdown.Timeout)
+	if c.Shutdown.DrainDelay < 0 {
+		problems = append(problems, fmt.Sprintf("shutdown.drain_delay (%s) must not be negative", EnvName("shutdown.drain_delay")))
+	}
+	positive("health.timeout", c.Health.Timeout)
+	positive("health.max_backoff", c.Health.MaxBackoff)
+	if c.Health.CacheTTL < 0 {
+		problems = append(problems, fmt.Sprintf("health.cache_ttl (%s) must not be negative", EnvName("health.cache_ttl")))
+	}
+	if u := c.Health.TransactionServiceURL; u != "" {
+		if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			problems = append(problems, fmt.Sprintf("health.transaction_service_url (%s) must be an absolute URL, got %q", EnvName("health.transaction_service_url"), u))
+		}
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter (%s) must be none, stdout or otlp, got %q", EnvName("tracing.exporter"), c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("tracing.sample_ratio (%s) must be between 0 and 1", EnvName("tracing.sample_ratio")))
+	}
+	if c.Token.KeyRefresh < 0 {
+		problems = append(problems, fmt.Sprintf("token.key_refresh (%s) must not be negative, 0 disables the refresh", EnvName("token.key_refresh")))
+	}
+	if c.Erasure.GracePeriod < 0 {
+		problems = append(problems, fmt.Sprintf("erasure.grace_period (%s) must not be negative", EnvName("erasure.grace_period")))
+	}
+	if c.Erasure.Interval < 0 {
+		problems = append(problems, fmt.Sprintf("erasure.interval (%s) must not be negative, 0 disables the erasure", EnvName("erasure.interval")))
+	}
+	if c.Erasure.BatchSize < 1 {
+		problems = append(problems, fmt.Sprintf("erasure.batch_size (%s) must be positive", EnvName("erasure.batch_size")))
+	}
+	if len(c.Encryption.MasterKeys) == 0 {
+		problems = append(problems, fmt.Sprintf("encryption.master_keys is required, set it in the config file or %s", EnvName("encryption.master_keys")))
+	} else if id := c.Encryption.ActiveKey; id != "" {
+		if _, ok := c.Encryption.MasterKeys[id]; !ok {
+			problems = append(problems, fmt.Sprintf("encryption.active_key (%s) must name one of encryption.master_keys, got %q", EnvName("encryption.active_key"), id))
+		}
+	}
+	for id, key := range c.Encryption.MasterKeys {
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != envelope.KeySize {
+			problems = append(problems, fmt.Sprintf("encryption.master_keys.%s (%s) must be %d base64 encoded bytes", id, EnvName("encryption.master_keys"), envelope.KeySize))
+		}
+	}
+	if key := c.Encryption.IndexKey; key != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) < envelope.MinIndexKeySize {
+			problems = append(problems, fmt.Sprintf("encryption.index_key (%s) must be at least %d base64 encoded bytes", EnvName("encryption.index_key"), envelope.MinIndexKeySize))
+		}
+	}
+	if c.Registration.MinAge < 0 {
+		problems = append(problems, fmt.Sprintf("registration.min_age (%s) must not be negative", EnvName("registration.min_age")))
+	}
+	if c.Password.MinLength < 1 {
+		problems = append(problems, fmt.Sprintf("password.min_length (%s) must be positive", EnvName("password.min_length")))
+	}
+	if c.Password.MaxLength != 0 && c.Password.MaxLength < c.Password.MinLength {
+		problems = append(problems, fmt.Sprintf("password.max_length (%s) must be 0 (unlimited) or at least password.min_length", EnvName("password.max_length")))
+	}
+	if c.Password.MinScore < password.ScoreVeryWeak || c.Password.MinScore > password.ScoreStrong {
+		problems = append(problems, fmt.Sprintf("password.min_score (%s) must be between %d and %d", EnvName("password.min_score"), password.ScoreVeryWeak, password.ScoreStrong))
+	}
+	if c.Password.History < 0 {
+		problems = append(problems, fmt.Sprintf("password.history (%s) must not be negative, 0 only prevents reusing the current password", EnvName("password.history")))
+	}
+	for role, maxAge := range c.Password.MaxAge {
+		if !domain.ValidRole(role) {
+			problems = append(problems, fmt.Sprintf("password.max_age (%s) has unknown role %q", EnvName("password.max_age"), role))
+		}
+		if maxAge <= 0 {
+			problems = append(problems, fmt.Sprintf("password.max_age.%s (%s) must be a positive number of days", role, EnvName("password.max_age")))
+		}
+	}
+	if f := c.Password.BreachedList; f != "" {
+		if _, err := os.Stat(f); err != nil {
+			problems = append(problems, fmt.Sprintf("password.breached_list (%s) must be a readable file: %v", EnvName("password.breached_list"), err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Package connection opens the redis and postgres connections described by the configuration.
+package connection
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transaction-service/config"
+
+	"github.com/go-redis/redis"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+// NewRedis returns a client that connects on first use.
+func NewRedis(c config.Redis) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     c.Address,
+		Password: c.Password,
+		DB:       0,
+	})
+}
+
+// Redis returns a client after checking redis answers.
+func Redis(c config.Redis) (*redis.Client, error) {
+
+	client := NewRedis(c)
+	if err := PingRedis(context.Background(), client); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func PingRedis(ctx context.Context, client *redis.Client) error {
+	if _, err := client.WithContext(ctx).Ping().Result(); err != nil {
+		return fmt.Errorf("redis ping: %w", err)
+	}
+	return nil
+}
+
+// NewPostgres returns a pool that connects on first use.
+func NewPostgres(c config.Postgres) (*pgxpool.Pool, error) {
+
+	poolConfig, err := pgxpool.ParseConfig(c.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("parse dsn config: %w", err)
+	}
+	poolConfig.LazyConnect = true
+
+	db, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connect postgres: %w", err)
+	}
+	return db, nil
+}
+
+// Postgres returns a pool after checking postgres answers.
+func Postgres(c config.Postgres) (*pgxpool.Pool, error) {
+
+	db, err := NewPostgres(c)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*1)
+	defer cancel()
+	if err := PingPostgres(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func PingPostgres(ctx context.Context, db *pgxpool.Pool) error {
+	if err := db.Ping(ctx); err != nil {
+		return fmt.Errorf("db ping: %w", err)
+	}
+	return nil
+}
+
+// Retry calls ping until it succeeds, backing off exponentially from one second
+// up to maxBackoff, and gives up only when ctx is done.
+func Retry(ctx context.Context, name string, maxBackoff time.Duration, ping func(ctx context.Context) error) error {
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Second*2)
+		err := ping(attemptCtx)
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				log.Info().Str("dependency", name).Int("attempts", attempt).Msg("dependency is up")
+			}
+			return nil
+		}
+
+		log.Warn().Err(err).Str("dependency", name).Dur("retry_in", backoff).Msg("dependency unavailable")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
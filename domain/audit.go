@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audit actions recorded for security relevant events.
+const (
+	AuditLoginSuccess        = "login.success"
+	AuditLoginFailure        = "login.failure"
+	AuditRegistration        = "user.register"
+	AuditRoleUpgrade         = "user.role_upgrade"
+	AuditTokenRevoked        = "token.revoke"
+	AuditUserDataView        = "admin.view_user"
+	AuditImpersonationStart  = "impersonation.start"
+	AuditImpersonationStop   = "impersonation.stop"
+	AuditPasswordReset       = "user.password_reset"
+	AuditPasswordChange      = "user.password_change"
+	AuditRoleChange          = "user.role_change"
+	AuditStatusChange        = "user.status_change"
+	AuditUserDelete          = "user.delete"
+	AuditUserErase           = "user.erase"
+	AuditKeyRotation         = "token.key_rotation"
+	AuditIINReencrypt        = "user.iin_reencrypt"
+	AuditCompanyMemberSet    = "company.member_set"
+	AuditCompanyMemberRemove = "company.member_remove"
+	AuditDataExport          = "user.data_export"
+)
+
+type AuditEvent struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// ActorID and TargetID are zero when unknown, e.g. a login with a wrong username.
+	ActorID   int64     `json:"actorId"`
+	TargetID  int64     `json:"targetId"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"createdAt"`
+	// PrevHash and Hash chain every record to its predecessor.
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+	// Salts holds a random salt per personal field (ip, user_agent, details).
+	// The chain commits to a salted digest of these fields rather than their
+	// values, so they can be erased later without breaking the chain.
+	Salts map[string]string `json:"-"`
+	// Redactions holds the salted digest of every personal field erased from
+	// the record, its salt is dropped so the digest no longer reveals the value.
+	Redactions map[string]string `json:"redactions,omitempty"`
+}
+
+// auditPersonalFields are the fields committed to the chain by a salted digest.
+var auditPersonalFields = []string{"ip", "user_agent", "details"}
+
+// NewSalts draws a fresh salt for every personal field of the event.
+func (e *AuditEvent) NewSalts() error {
+	e.Salts = make(map[string]string, len(auditPersonalFields))
+	for _, field := range auditPersonalFields {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		e.Salts[field] = hex.EncodeToString(salt)
+	}
+	return nil
+}
+
+// Redact erases a personal field and keeps its digest in Redactions, the
+// record still verifies against its hash afterwards. The user repository
+// performs the same steps in SQL when a user is erased.
+func (e *AuditEvent) Redact(field string) {
+	var value *string
+	switch field {
+	case "ip":
+		value = &e.IP
+	case "user_agent":
+		value = &e.UserAgent
+	case "details":
+		value = &e.Details
+	default:
+		return
+	}
+	if e.Redactions == nil {
+		e.Redactions = map[string]string{}
+	}
+	e.Redactions[field] = e.commitment(field, *value)
+	*value = ""
+	delete(e.Salts, field)
+}
+
+// commitment returns the salted digest of a personal field, or the digest
+// kept when the field was redacted and is still empty.
+func (e *AuditEvent) commitment(field, value string) string {
+	if digest, ok := e.Redactions[field]; ok && value == "" {
+		return digest
+	}
+	sum := sha256.Sum256([]byte(e.Salts[field] + value))
+	return hex.EncodeToString(sum[:])
+}
+
+// ComputeHash returns the chain hash of the event content linked to prevHash.
+// CreatedAt must already be truncated to the storage precision (microseconds).
+func (e *AuditEvent) ComputeHash(prevHash string) string {
+	content, _ := json.Marshal([]interface{}{
+		e.Action, e.ActorID, e.TargetID,
+		e.commitment("ip", e.IP), e.commitment("user_agent", e.UserAgent), e.commitment("details", e.Details),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(append([]byte(prevHash), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditCheckpoint is a signed snapshot of the chain head.
+type AuditCheckpoint struct {
+	ID        int64     `json:"id"`
+	EventID   int64     `json:"eventId"`
+	Hash      string    `json:"hash"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type AuditVerification struct {
+	Checked int64 `json:"checked"`
+	Legacy  int64 `json:"legacy"`
+	// Redacted counts the checked records whose personal data was erased.
+	Redacted    int64 `json:"redacted"`
+	Checkpoints int   `json:"checkpoints"`
+	// BrokenEventID is the first record that failed verification, zero when the chain is intact.
+	BrokenEventID int64  `json:"brokenEventId"`
+	Reason        string `json:"reason"`
+}
+
+func (v *AuditVerification) OK() bool {
+	return v.Reason == ""
+}
+
+type AuditFilter struct {
+	Action   string
+	ActorID  int64
+	TargetID int64
+	// SubjectID matches the events where the user is either the actor or the target.
+	SubjectID int64
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
+
+type AuditPage struct {
+	Events []AuditEvent `json:"events"`
+	Total  int64        `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+type AuditRepository interface {
+	InsertEvent(ctx context.Context, event *AuditEvent) error
+	ListEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, int64, error)
+	ListChain(ctx context.Context, afterID int64, limit int) ([]AuditEvent, error)
+	LastEvent(ctx context.Context) (*AuditEvent, error)
+	InsertCheckpoint(ctx context.Context, checkpoint *AuditCheckpoint) error
+	LastCheckpoint(ctx context.Context) (*AuditCheckpoint, error)
+	ListCheckpoints(ctx context.Context) ([]AuditCheckpoint, error)
+}
+
+type AuditUsecase interface {
+	RecordEvent(ctx context.Context, event *AuditEvent) error
+	ListEvents(ctx context.Context, filter AuditFilter) (*AuditPage, error)
+	CreateCheckpoint(ctx context.Context) (*AuditCheckpoint, error)
+	VerifyChain(ctx context.Context) (*AuditVerification, error)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Account types, a legal entity account registers a company along with the
+// user who acts on its behalf.
+const (
+	AccountIndividual  = "individual"
+	AccountLegalEntity = "legal_entity"
+)
+
+// Company is a legal entity identified by its BIN.
+type Company struct {
+	ID   int64  `json:"id"`
+	BIN  string `json:"bin"`
+	Name string `json:"name"`
+	// RegisteredAt is the month of the state registration encoded in the BIN.
+	RegisteredAt time.Time `json:"registeredAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// CompanyMember is a user acting on behalf of a company with a company role.
+type CompanyMember struct {
+	CompanyID int64     `json:"companyId"`
+	UserID    int64     `json:"userId"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	AddedAt   time.Time `json:"addedAt"`
+}
+
+// Membership is a company a user belongs to and the role of the user in it.
+type Membership struct {
+	Company Company `json:"company"`
+	Role    string  `json:"role"`
+}
+
+// CompanyProfile is a company with its members as seen by one of them.
+type CompanyProfile struct {
+	Company Company         `json:"company"`
+	Members []CompanyMember `json:"members"`
+	// Role is the company role of the user viewing the profile.
+	Role string `json:"role"`
+}
+
+// Can reports whether the user viewing the profile has perm in the company.
+func (p CompanyProfile) Can(perm Permission) bool {
+	return HasCompanyPermission(p.Role, perm)
+}
+
+type CompanyRepository interface {
+	GetCompanyByID(ctx context.Context, id int64) (*Company, error)
+	ListUserCompanies(ctx context.Context, userID int64) ([]Membership, error)
+	ListCompanyMembers(ctx context.Context, companyID int64) ([]CompanyMember, error)
+	GetCompanyMember(ctx context.Context, companyID, userID int64) (*CompanyMember, error)
+	// SetCompanyMember adds the member or changes the role of an existing one.
+	SetCompanyMember(ctx context.Context, member *CompanyMember) error
+	RemoveCompanyMember(ctx context.Context, companyID, userID int64) error
+	CountCompanyOwners(ctx context.Context, companyID int64) (int64, error)
+}
+
+type CompanyUsecase interface {
+	GetCompany(ctx context.Context, actor User, id int64) (*CompanyProfile, error)
+	ListUserCompanies(ctx context.Context, userID int64) ([]Membership, error)
+	SetCompanyMember(ctx context.Context, actor User, companyID int64, username, role string) (*CompanyMember, error)
+	RemoveCompanyMember(ctx context.Context, actor User, companyID, userID int64) error
+}
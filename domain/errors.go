@@ -2,14 +2,132 @@ package domain
 
 import "errors"
 
-type LogError struct {
-	Message string `json:"message"`
-	Err     error  `json:"err"`
-	Code    int    `json:"code"`
+// Kind classifies an error so the transport layer can pick a status code
+// without knowing where the error comes from.
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindValidation   Kind = "validation"
+)
+
+// Stable machine readable error codes, clients may rely on them.
+const (
+	CodeInternal           = "internal_error"
+	CodeInvalidInput       = "invalid_input"
+	CodeUnauthenticated    = "unauthenticated"
+	CodeAccessDenied       = "access_denied"
+	CodeUserNotFound       = "user_not_found"
+	CodeUserExists         = "user_already_exists"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeAccountLocked      = "account_locked"
+	CodeAccountPending     = "account_pending"
+	CodeAccountClosed      = "account_closed"
+	CodePasswordExpired    = "password_expired"
+	CodeUnknownRole        = "unknown_role"
+	CodeUnknownStatus      = "unknown_status"
+	CodeStatusTransition   = "invalid_status_transition"
+	CodeUserDeleted        = "user_deleted"
+	CodeInvalidToken       = "invalid_token"
+	CodeSessionNotFound    = "session_not_found"
+	CodeImpersonation      = "impersonation_not_allowed"
+	CodeCompanyNotFound    = "company_not_found"
+	CodeCompanyOwner       = "company_owner_required"
+	CodeAccountsNotFound   = "accounts_not_found"
+	CodeUpstream           = "upstream_error"
+)
+
+// Error is the error returned by usecases and handlers. Message is safe to
+// show to the client, Err is the cause and is only logged.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
 }
 
-func (l *LogError) Error() string {
-	return l.Message
+// Is matches errors by code, so errors.Is(err, &Error{Code: CodeUserNotFound})
+// holds whatever the cause.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
+func NotFound(code, message string, err error) error {
+	return &Error{KindNotFound, code, message, err}
+}
+
+func Conflict(code, message string, err error) error {
+	return &Error{KindConflict, code, message, err}
+}
+
+func Unauthorized(code, message string, err error) error {
+	return &Error{KindUnauthorized, code, message, err}
+}
+
+func Forbidden(code, message string, err error) error {
+	return &Error{KindForbidden, code, message, err}
+}
+
+func Validation(code, message string, err error) error {
+	return &Error{KindValidation, code, message, err}
+}
+
+// Internal hides the cause behind a generic code, message describes the
+// failed operation for the logs.
+func Internal(message string, err error) error {
+	return &Error{KindInternal, CodeInternal, message, err}
+}
+
+// AsError returns the first Error in the chain of err. Errors that were never
+// classified are internal errors.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{KindInternal, CodeInternal, "unexpected error", err}
+}
+
+// KindOf returns the kind of err, nil has no kind.
+func KindOf(err error) Kind {
+	if err == nil {
+		return ""
+	}
+	return AsError(err).Kind
+}
+
+// CodeOf returns the error code of err, nil has no code.
+func CodeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	return AsError(err).Code
 }
 
 var ErrorMetaNotFound = errors.New("meta info not found")
+
+// Repository errors, wrapped by repositories so usecases do not depend on the driver.
+var (
+	ErrNotFound  = errors.New("record not found")
+	ErrDuplicate = errors.New("record already exists")
+)
+
+// ErrUnauthenticated is returned when the authenticated user is missing from
+// the request context.
+var ErrUnauthenticated = Unauthorized(CodeUnauthenticated, "access denied", ErrorMetaNotFound)
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Data export formats.
+const (
+	ExportJSON = "json"
+	ExportZIP  = "zip"
+)
+
+// ExportProfile is the profile of a user as handed out in a data export, it
+// leaves out the password hash.
+type ExportProfile struct {
+	ID                int64      `json:"id"`
+	IIN               string     `json:"iin"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	Role              string     `json:"role"`
+	AccountType       string     `json:"accountType"`
+	Status            string     `json:"status"`
+	RegisterDate      string     `json:"registerDate"`
+	PasswordChangedAt time.Time  `json:"passwordChangedAt"`
+	DeletedAt         *time.Time `json:"deletedAt"`
+}
+
+// DataExport is the personal data kept about a user.
+type DataExport struct {
+	GeneratedAt   time.Time      `json:"generatedAt"`
+	Profile       ExportProfile  `json:"profile"`
+	Companies     []Membership   `json:"companies"`
+	StatusHistory []StatusChange `json:"statusHistory"`
+	Sessions      []Session      `json:"sessions"`
+	LoginHistory  []AuditEvent   `json:"loginHistory"`
+	// AuditEvents are the other events where the user is the actor or the target.
+	AuditEvents []AuditEvent `json:"auditEvents"`
+	Accounts    []Accounts   `json:"accounts"`
+	// Unavailable names the sections that could not be collected, e.g. the
+	// accounts when the transaction service is down.
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+type ExportUsecase interface {
+	// ExportUserData collects the data of user id kept by this service, the
+	// linked accounts are fetched by the caller.
+	ExportUserData(ctx context.Context, id int64) (*DataExport, error)
+}
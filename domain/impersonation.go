@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Actor identifies the staff member behind an impersonation session.
+type Actor struct {
+	ID   int64  `json:"id"`
+	Role string `json:"role"`
+}
+
+type Impersonation struct {
+	ID        int64      `json:"id"`
+	ActorID   int64      `json:"actorId"`
+	TargetID  int64      `json:"targetId"`
+	Reason    string     `json:"reason"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"userAgent"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt"`
+}
+
+type ImpersonationRepository interface {
+	CreateImpersonation(ctx context.Context, imp *Impersonation) error
+	EndImpersonation(ctx context.Context, actorID int64, endedAt time.Time) error
+}
+
+type ImpersonationUsecase interface {
+	StartImpersonation(ctx context.Context, actor User, targetID int64, reason, ip, userAgent string) (*User, error)
+	StopImpersonation(ctx context.Context, actorID int64) error
+}
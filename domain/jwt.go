@@ -1,15 +1,66 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
+// Token exchange identifiers from RFC 8693.
+const (
+	GrantTypeTokenExchange  = "urn:ietf:params:oauth:grant-type:token-exchange"
+	TokenTypeAccessToken    = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT            = "urn:ietf:params:oauth:token-type:jwt"
+	ErrInvalidRequest       = "invalid_request"
+	ErrInvalidClient        = "invalid_client"
+	ErrInvalidGrant         = "invalid_grant"
+	ErrInvalidScope         = "invalid_scope"
+	ErrInvalidTarget        = "invalid_target"
+	ErrUnsupportedGrantType = "unsupported_grant_type"
+
+	// ScopeIINRead lets an exchanged token carry the IIN of its subject.
+	ScopeIINRead = "iin:read"
+)
+
 type JwtToken struct {
 	AccessSecret string
 	// RedisConn    *redis.Client
 	AccessTtl time.Duration
+
+	// ImpersonationTtl bounds the lifetime of impersonation sessions.
+	ImpersonationTtl time.Duration
+
+	// ExchangeTtl is the upper bound for the lifetime of exchanged tokens.
+	ExchangeTtl time.Duration
+	// ExchangeClients holds the secret of every downstream service allowed to
+	// exchange tokens, by client ID. A client only obtains tokens for itself.
+	ExchangeClients map[string]string
+	// ExchangeScopes lists the scopes an exchanged token may carry.
+	ExchangeScopes []string
+}
+
+// TokenExchangeRequest holds the parameters of an RFC 8693 token exchange.
+type TokenExchangeRequest struct {
+	// ClientID and ClientSecret authenticate the calling service.
+	ClientID           string
+	ClientSecret       string
+	GrantType          string
+	SubjectToken       string
+	SubjectTokenType   string
+	ActorToken         string
+	ActorTokenType     string
+	RequestedTokenType string
+	Audience           []string
+	Scope              []string
+}
+
+type TokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope,omitempty"`
 }
 
 type JwtTokenUsecase interface {
@@ -18,11 +69,46 @@ type JwtTokenUsecase interface {
 	ParseTokenAndGetRole(token string) (string, error)
 	JWTErrorChecker(err error, c echo.Context) error
 	GetAccessTTL() time.Duration
-	InsertToken(id int64, token string) error
-	FindToken(id int64, token string) (bool, error)
+	InsertToken(ctx context.Context, id int64, token string) error
+	FindToken(ctx context.Context, id int64, token string) (bool, error)
+	ExchangeToken(ctx context.Context, req *TokenExchangeRequest) (*TokenExchangeResponse, error)
+	GenerateImpersonationToken(target *User, actor Actor) (string, error)
+	ParseTokenAndGetActor(token string) (*Actor, error)
+	InsertImpersonationToken(ctx context.Context, actorID int64, token string) error
+	FindImpersonationToken(ctx context.Context, actorID int64, token string) (bool, error)
+	DeleteImpersonationToken(ctx context.Context, actorID int64) error
+	RevokeToken(ctx context.Context, id int64) error
+	ListSessions(ctx context.Context) ([]Session, error)
+	ReloadSigningKeys(ctx context.Context) error
+	RotateSigningKey(ctx context.Context) (*SigningKey, error)
 }
 
 type JwtTokenRepo interface {
-	InsertTokenRepo(key, token string, ttl time.Duration) error
-	FindTokenRepo(key, token string) (bool, error)
+	InsertTokenRepo(ctx context.Context, key, token string, ttl time.Duration) error
+	FindTokenRepo(ctx context.Context, key, token string) (bool, error)
+	DeleteTokenRepo(ctx context.Context, key string) error
+	ListTokensRepo(ctx context.Context, pattern string) (map[string]time.Duration, error)
+}
+
+// Session is an active token kept in redis.
+type Session struct {
+	UserID int64 `json:"userId"`
+	// ActorID is set for impersonation sessions, UserID is then unknown.
+	ActorID int64         `json:"actorId"`
+	TTL     time.Duration `json:"ttl"`
+}
+
+// SigningKey is a token signing secret identified by the kid header.
+type SigningKey struct {
+	ID        string
+	Secret    []byte
+	CreatedAt time.Time
+	RetiredAt *time.Time
+}
+
+type SigningKeyRepository interface {
+	ListKeys(ctx context.Context, retiredAfter time.Time) ([]SigningKey, error)
+	CreateKey(ctx context.Context, key *SigningKey) error
+	RetireKeys(ctx context.Context, exceptID string, retiredAt time.Time) error
+	DeleteRetiredKeys(ctx context.Context, retiredBefore time.Time) error
 }
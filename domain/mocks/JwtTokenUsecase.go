@@ -3,10 +3,12 @@
 package mocks
 
 import (
+	context "context"
+	time "time"
+	domain "transaction-service/domain"
+
 	echo "github.com/labstack/echo/v4"
 	mock "github.com/stretchr/testify/mock"
-
-	time "time"
 )
 
 // JwtTokenUsecase is an autogenerated mock type for the JwtTokenUsecase type
@@ -14,18 +16,104 @@ type JwtTokenUsecase struct {
 	mock.Mock
 }
 
-// FindToken provides a mock function with given fields: id, token
-func (_m *JwtTokenUsecase) FindToken(id int64, token string) bool {
-	ret := _m.Called(id, token)
+// DeleteImpersonationToken provides a mock function with given fields: ctx, actorID
+func (_m *JwtTokenUsecase) DeleteImpersonationToken(ctx context.Context, actorID int64) error {
+	ret := _m.Called(ctx, actorID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, actorID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExchangeToken provides a mock function with given fields: ctx, req
+func (_m *JwtTokenUsecase) ExchangeToken(ctx context.Context, req *domain.TokenExchangeRequest) (*domain.TokenExchangeResponse, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *domain.TokenExchangeResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.TokenExchangeRequest) *domain.TokenExchangeResponse); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TokenExchangeResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.TokenExchangeRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindImpersonationToken provides a mock function with given fields: ctx, actorID, token
+func (_m *JwtTokenUsecase) FindImpersonationToken(ctx context.Context, actorID int64, token string) (bool, error) {
+	ret := _m.Called(ctx, actorID, token)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(int64, string) bool); ok {
-		r0 = rf(id, token)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(ctx, actorID, token)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, actorID, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindToken provides a mock function with given fields: ctx, id, token
+func (_m *JwtTokenUsecase) FindToken(ctx context.Context, id int64, token string) (bool, error) {
+	ret := _m.Called(ctx, id, token)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(ctx, id, token)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, id, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateImpersonationToken provides a mock function with given fields: target, actor
+func (_m *JwtTokenUsecase) GenerateImpersonationToken(target *domain.User, actor domain.Actor) (string, error) {
+	ret := _m.Called(target, actor)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(*domain.User, domain.Actor) string); ok {
+		r0 = rf(target, actor)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*domain.User, domain.Actor) error); ok {
+		r1 = rf(target, actor)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // GenerateToken provides a mock function with given fields: id, role, iin
@@ -63,13 +151,27 @@ func (_m *JwtTokenUsecase) GetAccessTTL() time.Duration {
 	return r0
 }
 
-// InsertToken provides a mock function with given fields: id, token
-func (_m *JwtTokenUsecase) InsertToken(id int64, token string) error {
-	ret := _m.Called(id, token)
+// InsertImpersonationToken provides a mock function with given fields: ctx, actorID, token
+func (_m *JwtTokenUsecase) InsertImpersonationToken(ctx context.Context, actorID int64, token string) error {
+	ret := _m.Called(ctx, actorID, token)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64, string) error); ok {
-		r0 = rf(id, token)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, actorID, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertToken provides a mock function with given fields: ctx, id, token
+func (_m *JwtTokenUsecase) InsertToken(ctx context.Context, id int64, token string) error {
+	ret := _m.Called(ctx, id, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, id, token)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -91,6 +193,52 @@ func (_m *JwtTokenUsecase) JWTErrorChecker(err error, c echo.Context) error {
 	return r0
 }
 
+// ListSessions provides a mock function with given fields: ctx
+func (_m *JwtTokenUsecase) ListSessions(ctx context.Context) ([]domain.Session, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.Session
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Session); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ParseTokenAndGetActor provides a mock function with given fields: token
+func (_m *JwtTokenUsecase) ParseTokenAndGetActor(token string) (*domain.Actor, error) {
+	ret := _m.Called(token)
+
+	var r0 *domain.Actor
+	if rf, ok := ret.Get(0).(func(string) *domain.Actor); ok {
+		r0 = rf(token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Actor)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ParseTokenAndGetID provides a mock function with given fields: token
 func (_m *JwtTokenUsecase) ParseTokenAndGetID(token string) (int64, error) {
 	ret := _m.Called(token)
@@ -132,3 +280,54 @@ func (_m *JwtTokenUsecase) ParseTokenAndGetRole(token string) (string, error) {
 
 	return r0, r1
 }
+
+// ReloadSigningKeys provides a mock function with given fields: ctx
+func (_m *JwtTokenUsecase) ReloadSigningKeys(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeToken provides a mock function with given fields: ctx, id
+func (_m *JwtTokenUsecase) RevokeToken(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateSigningKey provides a mock function with given fields: ctx
+func (_m *JwtTokenUsecase) RotateSigningKey(ctx context.Context) (*domain.SigningKey, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *domain.SigningKey
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.SigningKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SigningKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 	domain "transaction-service/domain"
 
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +15,27 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// CountUsersByRole provides a mock function with given fields: ctx, role
+func (_m *UserRepository) CountUsersByRole(ctx context.Context, role string) (int64, error) {
+	ret := _m.Called(ctx, role)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateUser provides a mock function with given fields: ctx, user
 func (_m *UserRepository) CreateUser(ctx context.Context, user *domain.User) error {
 	ret := _m.Called(ctx, user)
@@ -28,6 +50,34 @@ func (_m *UserRepository) CreateUser(ctx context.Context, user *domain.User) err
 	return r0
 }
 
+// DeleteUserRepo provides a mock function with given fields: ctx, change
+func (_m *UserRepository) DeleteUserRepo(ctx context.Context, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EraseUserRepo provides a mock function with given fields: ctx, id
+func (_m *UserRepository) EraseUserRepo(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetAllUsers provides a mock function with given fields: ctx
 func (_m *UserRepository) GetAllUsers(ctx context.Context) ([]domain.User, error) {
 	ret := _m.Called(ctx)
@@ -51,6 +101,48 @@ func (_m *UserRepository) GetAllUsers(ctx context.Context) ([]domain.User, error
 	return r0, r1
 }
 
+// GetPasswordChangedAtRepo provides a mock function with given fields: ctx, id
+func (_m *UserRepository) GetPasswordChangedAtRepo(ctx context.Context, id int64) (time.Time, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func(context.Context, int64) time.Time); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPasswordRepo provides a mock function with given fields: ctx, id
+func (_m *UserRepository) GetPasswordRepo(ctx context.Context, id int64) (string, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, int64) string); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserByID provides a mock function with given fields: ctx, id
 func (_m *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
 	ret := _m.Called(ctx, id)
@@ -120,6 +212,117 @@ func (_m *UserRepository) GetUserByUsername(ctx context.Context, username string
 	return r0, r1
 }
 
+// ListErasableRepo provides a mock function with given fields: ctx, deletedBefore, limit
+func (_m *UserRepository) ListErasableRepo(ctx context.Context, deletedBefore time.Time, limit int) ([]int64, error) {
+	ret := _m.Called(ctx, deletedBefore, limit)
+
+	var r0 []int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []int64); ok {
+		r0 = rf(ctx, deletedBefore, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, deletedBefore, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPasswordHistoryRepo provides a mock function with given fields: ctx, id, limit
+func (_m *UserRepository) ListPasswordHistoryRepo(ctx context.Context, id int64, limit int) ([]string, error) {
+	ret := _m.Called(ctx, id, limit)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int) []string); ok {
+		r0 = rf(ctx, id, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int) error); ok {
+		r1 = rf(ctx, id, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListStatusHistoryRepo provides a mock function with given fields: ctx, id
+func (_m *UserRepository) ListStatusHistoryRepo(ctx context.Context, id int64) ([]domain.StatusChange, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []domain.StatusChange
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []domain.StatusChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.StatusChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetRoleRepo provides a mock function with given fields: ctx, username, role
+func (_m *UserRepository) SetRoleRepo(ctx context.Context, username string, role string) error {
+	ret := _m.Called(ctx, username, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetStatusRepo provides a mock function with given fields: ctx, change
+func (_m *UserRepository) SetStatusRepo(ctx context.Context, change *domain.StatusChange) error {
+	ret := _m.Called(ctx, change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.StatusChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePasswordRepo provides a mock function with given fields: ctx, id, password, keep
+func (_m *UserRepository) UpdatePasswordRepo(ctx context.Context, id int64, password string, keep int) error {
+	ret := _m.Called(ctx, id, password, keep)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, int) error); ok {
+		r0 = rf(ctx, id, password, keep)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpgradeUserRepo provides a mock function with given fields: ctx, username
 func (_m *UserRepository) UpgradeUserRepo(ctx context.Context, username string) error {
 	ret := _m.Called(ctx, username)
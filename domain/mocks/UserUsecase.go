@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 	domain "transaction-service/domain"
+	password "transaction-service/password"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -14,6 +15,110 @@ type UserUsecase struct {
 	mock.Mock
 }
 
+// BootstrapAdminUsecase provides a mock function with given fields: ctx, admin
+func (_m *UserUsecase) BootstrapAdminUsecase(ctx context.Context, admin *domain.User) (bool, error) {
+	ret := _m.Called(ctx, admin)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) bool); ok {
+		r0 = rf(ctx, admin)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User) error); ok {
+		r1 = rf(ctx, admin)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePasswordUsecase provides a mock function with given fields: ctx, id, current, next
+func (_m *UserUsecase) ChangePasswordUsecase(ctx context.Context, id int64, current string, next string) (*domain.User, error) {
+	ret := _m.Called(ctx, id, current, next)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) *domain.User); ok {
+		r0 = rf(ctx, id, current, next)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string) error); ok {
+		r1 = rf(ctx, id, current, next)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangeStatusUsecase provides a mock function with given fields: ctx, actorID, username, status, reason
+func (_m *UserUsecase) ChangeStatusUsecase(ctx context.Context, actorID int64, username string, status string, reason string) (*domain.User, error) {
+	ret := _m.Called(ctx, actorID, username, status, reason)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, string) *domain.User); ok {
+		r0 = rf(ctx, actorID, username, status, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string, string) error); ok {
+		r1 = rf(ctx, actorID, username, status, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckPasswordUsecase provides a mock function with given fields: user
+func (_m *UserUsecase) CheckPasswordUsecase(user *domain.User) password.Report {
+	ret := _m.Called(user)
+
+	var r0 password.Report
+	if rf, ok := ret.Get(0).(func(*domain.User) password.Report); ok {
+		r0 = rf(user)
+	} else {
+		r0 = ret.Get(0).(password.Report)
+	}
+
+	return r0
+}
+
+// CloseAccountUsecase provides a mock function with given fields: ctx, id, password
+func (_m *UserUsecase) CloseAccountUsecase(ctx context.Context, id int64, password string) (*domain.User, error) {
+	ret := _m.Called(ctx, id, password)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *domain.User); ok {
+		r0 = rf(ctx, id, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, id, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateUserUsecase provides a mock function with given fields: ctx, user
 func (_m *UserUsecase) CreateUserUsecase(ctx context.Context, user *domain.User) error {
 	ret := _m.Called(ctx, user)
@@ -28,6 +133,43 @@ func (_m *UserUsecase) CreateUserUsecase(ctx context.Context, user *domain.User)
 	return r0
 }
 
+// CreateUserWithRoleUsecase provides a mock function with given fields: ctx, user, role
+func (_m *UserUsecase) CreateUserWithRoleUsecase(ctx context.Context, user *domain.User, role string) error {
+	ret := _m.Called(ctx, user, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) error); ok {
+		r0 = rf(ctx, user, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUserUsecase provides a mock function with given fields: ctx, actorID, username, reason
+func (_m *UserUsecase) DeleteUserUsecase(ctx context.Context, actorID int64, username string, reason string) (*domain.User, error) {
+	ret := _m.Called(ctx, actorID, username, reason)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) *domain.User); ok {
+		r0 = rf(ctx, actorID, username, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string) error); ok {
+		r1 = rf(ctx, actorID, username, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllUsecase provides a mock function with given fields: ctx
 func (_m *UserUsecase) GetAllUsecase(ctx context.Context) ([]domain.User, error) {
 	ret := _m.Called(ctx)
@@ -120,6 +262,96 @@ func (_m *UserUsecase) GetUserByNameUsecase(ctx context.Context, name string) (*
 	return r0, r1
 }
 
+// PasswordExpiredUsecase provides a mock function with given fields: ctx, id, role
+func (_m *UserUsecase) PasswordExpiredUsecase(ctx context.Context, id int64, role string) (bool, error) {
+	ret := _m.Called(ctx, id, role)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(ctx, id, role)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, id, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetPasswordUsecase provides a mock function with given fields: ctx, username, password
+func (_m *UserUsecase) ResetPasswordUsecase(ctx context.Context, username string, password string) (*domain.User, error) {
+	ret := _m.Called(ctx, username, password)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.User); ok {
+		r0 = rf(ctx, username, password)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, username, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetRoleUsecase provides a mock function with given fields: ctx, username, role
+func (_m *UserUsecase) SetRoleUsecase(ctx context.Context, username string, role string) (*domain.User, error) {
+	ret := _m.Called(ctx, username, role)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.User); ok {
+		r0 = rf(ctx, username, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, username, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StatusHistoryUsecase provides a mock function with given fields: ctx, id
+func (_m *UserUsecase) StatusHistoryUsecase(ctx context.Context, id int64) ([]domain.StatusChange, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []domain.StatusChange
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []domain.StatusChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.StatusChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpgradeUserUsecase provides a mock function with given fields: ctx, username
 func (_m *UserUsecase) UpgradeUserUsecase(ctx context.Context, username string) error {
 	ret := _m.Called(ctx, username)
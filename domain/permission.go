@@ -0,0 +1,81 @@
+package domain
+
+// Permission is a named capability granted to a role.
+type Permission string
+
+const (
+	PermImpersonate Permission = "user:impersonate"
+	PermAuditRead   Permission = "audit:read"
+	PermUserStatus  Permission = "user:status"
+	PermUserDelete  Permission = "user:delete"
+	// PermPIIRead shows personal data such as IINs unmasked.
+	PermPIIRead Permission = "pii:read"
+)
+
+// Roles lists every role a user can have.
+var Roles = []string{"user", "support", "admin"}
+
+// RolePermissions maps a role to the permissions it grants.
+var RolePermissions = map[string][]Permission{
+	"admin":   {PermImpersonate, PermAuditRead, PermUserStatus, PermUserDelete, PermPIIRead},
+	"support": {PermImpersonate},
+}
+
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Company permissions are granted by a company role and only within that company.
+const (
+	PermCompanyRead    Permission = "company:read"
+	PermCompanyMembers Permission = "company:members"
+)
+
+const (
+	CompanyOwner   = "owner"
+	CompanyManager = "manager"
+	CompanyViewer  = "viewer"
+)
+
+// CompanyRoles lists every role a user can have within a company.
+var CompanyRoles = []string{CompanyOwner, CompanyManager, CompanyViewer}
+
+// CompanyRolePermissions maps a company role to the permissions it grants.
+// Managers handle the members but only an owner grants or takes the owner role.
+var CompanyRolePermissions = map[string][]Permission{
+	CompanyOwner:   {PermCompanyRead, PermCompanyMembers},
+	CompanyManager: {PermCompanyRead, PermCompanyMembers},
+	CompanyViewer:  {PermCompanyRead},
+}
+
+func HasCompanyPermission(role string, perm Permission) bool {
+	for _, p := range CompanyRolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidCompanyRole(role string) bool {
+	for _, r := range CompanyRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
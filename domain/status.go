@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Account statuses, only active accounts can sign in.
+const (
+	StatusPending   = "pending"
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusClosed    = "closed"
+)
+
+// StatusChange is a transition of the status of an account.
+type StatusChange struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"userId"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	// ActorID is zero for the changes made by the service itself, e.g. at registration.
+	ActorID   int64     `json:"actorId"`
+	Reason    string    `json:"reason"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// StatusError returns why user cannot use the account, nil when it is active.
+func StatusError(user *User) error {
+	cause := fmt.Errorf("user %d is %s", user.ID, user.Status)
+	switch user.Status {
+	case StatusActive:
+		return nil
+	case StatusPending:
+		return Forbidden(CodeAccountPending, "account is pending activation", cause)
+	case StatusSuspended:
+		return Forbidden(CodeAccountLocked, "account is suspended", cause)
+	}
+	return Forbidden(CodeAccountClosed, "account is closed", cause)
+}
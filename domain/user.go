@@ -2,15 +2,37 @@ package domain
 
 import (
 	"context"
+	"time"
+	"transaction-service/iin"
+	"transaction-service/password"
 )
 
 type User struct {
 	ID           int64  `json:"id"`
 	IIN          string `json:"iin"`
 	Username     string `json:"username"`
+	Email        string `json:"email"`
 	Password     string `json:"password"`
 	Role         string `json:"role"`
 	RegisterDate string `json:"registerdate"`
+	Status       string `json:"status"`
+	AccountType  string `json:"accountType"`
+	// DeletedAt is set once the account is deleted, its personal data is
+	// erased when the grace period is over.
+	DeletedAt *time.Time `json:"deletedAt"`
+	// Company is the company registered along with a legal entity account.
+	Company *Company `json:"-"`
+	// Actor is set when the request is made by staff impersonating this user.
+	Actor *Actor `json:"-"`
+}
+
+// ParsedIIN returns what the IIN of the user encodes, nil when it is not valid.
+func (u User) ParsedIIN() *iin.IIN {
+	id, err := iin.Parse(u.IIN)
+	if err != nil {
+		return nil
+	}
+	return &id
 }
 
 type Accounts struct {
@@ -18,11 +40,55 @@ type Accounts struct {
 	Balance         int64  `json:"balance"`
 	RegisterDate    string `json:"registerDate"`
 	LastTransaction string `json:"lasttransaction"`
+	// Hidden is set by Present when the viewer may not see the balance.
+	Hidden bool `json:"-"`
 }
 
 type UserInfo struct {
 	User     User
 	Accounts []Accounts
+	// StatusHistory is only loaded for staff.
+	StatusHistory []StatusChange
+	// Identity is what the IIN encodes, set by Present before the IIN is masked.
+	Identity *Identity
+}
+
+// Identity is the date of birth, age and gender an IIN encodes.
+type Identity struct {
+	BirthDate time.Time
+	Age       int
+	Gender    iin.Gender
+}
+
+// Present returns the info as viewer may see it, without the password hash and
+// with the IIN, account numbers and balances masked unless viewer is its owner
+// or can read personal data.
+func (i UserInfo) Present(viewer User) UserInfo {
+	i.User.Password = ""
+	if id := i.User.ParsedIIN(); id != nil {
+		i.Identity = &Identity{BirthDate: id.BirthDate(), Age: id.Age(), Gender: id.Gender()}
+	}
+	owner := viewer.ID == i.User.ID && viewer.Actor == nil
+	if !owner && !CanReadPII(viewer) {
+		i.User.IIN = iin.Mask(i.User.IIN)
+		// a copy, the caller's accounts are left as they are
+		accounts := make([]Accounts, len(i.Accounts))
+		for n, a := range i.Accounts {
+			accounts[n] = Accounts{Number: iin.Mask(a.Number), RegisterDate: a.RegisterDate, LastTransaction: a.LastTransaction, Hidden: true}
+		}
+		i.Accounts = accounts
+	}
+	return i
+}
+
+// CanReadPII reports whether viewer sees personal data unmasked. A staff member
+// impersonating a user keeps their own permission.
+func CanReadPII(viewer User) bool {
+	role := viewer.Role
+	if viewer.Actor != nil {
+		role = viewer.Actor.Role
+	}
+	return HasPermission(role, PermPIIRead)
 }
 
 type UserRepository interface {
@@ -32,13 +98,53 @@ type UserRepository interface {
 	GetUserByIIN(ctx context.Context, iin string) (*User, error)
 	GetAllUsers(ctx context.Context) ([]User, error)
 	UpgradeUserRepo(ctx context.Context, username string) error
+	CountUsersByRole(ctx context.Context, role string) (int64, error)
+	// UpdatePasswordRepo keeps the keep most recent previous passwords in the history.
+	UpdatePasswordRepo(ctx context.Context, id int64, password string, keep int) error
+	GetPasswordRepo(ctx context.Context, id int64) (string, error)
+	GetPasswordChangedAtRepo(ctx context.Context, id int64) (time.Time, error)
+	ListPasswordHistoryRepo(ctx context.Context, id int64, limit int) ([]string, error)
+	SetRoleRepo(ctx context.Context, username, role string) error
+	// SetStatusRepo moves the user from change.From to change.To and records the
+	// change, ErrNotFound when the user is not in change.From. Closing the
+	// account marks it deleted.
+	SetStatusRepo(ctx context.Context, change *StatusChange) error
+	ListStatusHistoryRepo(ctx context.Context, id int64) ([]StatusChange, error)
+	// DeleteUserRepo closes the account like SetStatusRepo and marks it deleted.
+	DeleteUserRepo(ctx context.Context, change *StatusChange) error
+	// ListErasableRepo returns the IDs of the accounts deleted before deletedBefore and not erased yet.
+	ListErasableRepo(ctx context.Context, deletedBefore time.Time, limit int) ([]int64, error)
+	EraseUserRepo(ctx context.Context, id int64) error
 }
 
 type UserUsecase interface {
+	// CreateUserUsecase registers user with the user role, user.Role is ignored.
 	CreateUserUsecase(ctx context.Context, user *User) error
+	// CreateUserWithRoleUsecase registers user with role, for staff tooling.
+	CreateUserWithRoleUsecase(ctx context.Context, user *User, role string) error
 	GetUserByNameUsecase(ctx context.Context, name string) (*User, error) //пересмотреть
 	GetUserByIINUsecase(ctx context.Context, iin string) (*User, error)
 	GetUserByIDUsecase(ctx context.Context, id int64) (*User, error)
 	GetAllUsecase(ctx context.Context) ([]User, error)
 	UpgradeUserUsecase(ctx context.Context, username string) error
+	BootstrapAdminUsecase(ctx context.Context, admin *User) (bool, error)
+	ResetPasswordUsecase(ctx context.Context, username, password string) (*User, error)
+	SetRoleUsecase(ctx context.Context, username, role string) (*User, error)
+	// ChangeStatusUsecase moves the account along its lifecycle, actorID is the staff member doing it.
+	ChangeStatusUsecase(ctx context.Context, actorID int64, username, status, reason string) (*User, error)
+	StatusHistoryUsecase(ctx context.Context, id int64) ([]StatusChange, error)
+	// CloseAccountUsecase deletes the account of a user who confirmed it with the password.
+	CloseAccountUsecase(ctx context.Context, id int64, password string) (*User, error)
+	DeleteUserUsecase(ctx context.Context, actorID int64, username, reason string) (*User, error)
+	CheckPasswordUsecase(user *User) password.Report
+	ChangePasswordUsecase(ctx context.Context, id int64, current, next string) (*User, error)
+	// PasswordExpiredUsecase reports whether the user must change the password before going on.
+	PasswordExpiredUsecase(ctx context.Context, id int64, role string) (bool, error)
+}
+
+// ErasureUsecase erases the personal data of the accounts deleted longer than
+// the grace period ago.
+type ErasureUsecase interface {
+	// EraseDeleted returns the IDs of the erased accounts.
+	EraseDeleted(ctx context.Context, now time.Time) ([]int64, error)
 }
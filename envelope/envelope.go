@@ -0,0 +1,191 @@
+// Package envelope encrypts values at rest. Every value is sealed with its own
+// AES-GCM data key, which is itself sealed with a master key, so rotating the
+// master key only re-wraps the data keys. A keyed HMAC of the value, the blind
+// index, allows equality lookups without decrypting.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// KeySize is the size of master and data keys, AES-256.
+const KeySize = 32
+
+// MinIndexKeySize is the smallest accepted blind index key.
+const MinIndexKeySize = 32
+
+const (
+	version   = 1
+	nonceSize = 12
+	// wrappedSize is a sealed data key: nonce, key and GCM tag.
+	wrappedSize = nonceSize + KeySize + 16
+)
+
+var ErrUnknownKey = errors.New("envelope: unknown master key")
+
+var errMalformed = errors.New("envelope: malformed ciphertext")
+
+// Sealed is an encrypted value and the ID of the master key its data key is
+// wrapped with.
+type Sealed struct {
+	KeyID string
+	Data  []byte
+}
+
+// Keyring holds the master keys. New values are wrapped with the active key,
+// the other keys are kept to open the values sealed before a rotation.
+type Keyring struct {
+	activeID string
+	masters  map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// NewKeyring checks the keys and returns a keyring wrapping with activeID.
+func NewKeyring(masterKeys map[string][]byte, activeID string, indexKey []byte) (*Keyring, error) {
+	k := &Keyring{activeID: activeID, masters: map[string]cipher.AEAD{}, indexKey: indexKey}
+	for id, key := range masterKeys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("envelope: master key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.masters[id] = aead
+	}
+	if _, ok := k.masters[activeID]; !ok {
+		return nil, fmt.Errorf("envelope: active master key %q is not configured", activeID)
+	}
+	if len(indexKey) < MinIndexKeySize {
+		return nil, fmt.Errorf("envelope: index key must be at least %d bytes, got %d", MinIndexKeySize, len(indexKey))
+	}
+	return k, nil
+}
+
+// ActiveID is the ID of the master key new values are wrapped with.
+func (k *Keyring) ActiveID() string {
+	return k.activeID
+}
+
+// Seal encrypts plaintext with a new data key wrapped by the active master key.
+func (k *Keyring) Seal(plaintext []byte) (Sealed, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return Sealed{}, err
+	}
+	wrapped, err := seal(k.masters[k.activeID], dataKey, []byte(k.activeID))
+	if err != nil {
+		return Sealed{}, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+	body, err := seal(aead, plaintext, nil)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	data := make([]byte, 0, 1+len(wrapped)+len(body))
+	data = append(data, version)
+	data = append(data, wrapped...)
+	return Sealed{KeyID: k.activeID, Data: append(data, body...)}, nil
+}
+
+// Open decrypts a sealed value.
+func (k *Keyring) Open(s Sealed) ([]byte, error) {
+	wrapped, body, err := split(s.Data)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := k.unwrap(s.KeyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, body, nil)
+}
+
+// Rewrap wraps the data key of s with the active master key, the value itself
+// is not decrypted. ok is false when s already uses the active key.
+func (k *Keyring) Rewrap(s Sealed) (_ Sealed, ok bool, err error) {
+	if s.KeyID == k.activeID {
+		return s, false, nil
+	}
+	wrapped, body, err := split(s.Data)
+	if err != nil {
+		return s, false, err
+	}
+	dataKey, err := k.unwrap(s.KeyID, wrapped)
+	if err != nil {
+		return s, false, err
+	}
+	if wrapped, err = seal(k.masters[k.activeID], dataKey, []byte(k.activeID)); err != nil {
+		return s, false, err
+	}
+
+	data := make([]byte, 0, len(s.Data))
+	data = append(data, version)
+	data = append(data, wrapped...)
+	return Sealed{KeyID: k.activeID, Data: append(data, body...)}, true, nil
+}
+
+// Index returns the blind index of value, equal values have equal indexes.
+func (k *Keyring) Index(value []byte) []byte {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write(value)
+	return mac.Sum(nil)
+}
+
+func (k *Keyring) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	master, ok := k.masters[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	return open(master, wrapped, []byte(keyID))
+}
+
+func split(data []byte) (wrapped, body []byte, err error) {
+	if len(data) < 1+wrappedSize+nonceSize || data[0] != version {
+		return nil, nil, errMalformed
+	}
+	return data[1 : 1+wrappedSize], data[1+wrappedSize:], nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, nonceSize, nonceSize+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < nonceSize {
+		return nil, errMalformed
+	}
+	plaintext, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], additional)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,74 @@
+package envelope_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"transaction-service/envelope"
+)
+
+func key(b byte) []byte {
+	return bytes.Repeat([]byte{b}, envelope.KeySize)
+}
+
+func TestKeyring(t *testing.T) {
+	old, err := envelope.NewKeyring(map[string][]byte{"k1": key(1)}, "k1", key(9))
+	require.NoError(t, err)
+
+	sealed, err := old.Seal([]byte("940217450216"))
+	require.NoError(t, err)
+	assert.Equal(t, "k1", sealed.KeyID)
+	assert.NotContains(t, string(sealed.Data), "940217450216")
+
+	again, err := old.Seal([]byte("940217450216"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed.Data, again.Data)
+	assert.Equal(t, old.Index([]byte("940217450216")), old.Index([]byte("940217450216")))
+	assert.NotEqual(t, old.Index([]byte("940217450216")), old.Index([]byte("940217450217")))
+
+	plaintext, err := old.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "940217450216", string(plaintext))
+
+	// rotation keeps the old key to open the values not re-wrapped yet
+	rotated, err := envelope.NewKeyring(map[string][]byte{"k1": key(1), "k2": key(2)}, "k2", key(9))
+	require.NoError(t, err)
+	rewrapped, ok, err := rotated.Rewrap(sealed)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "k2", rewrapped.KeyID)
+	assert.Equal(t, sealed.Data[len(sealed.Data)-20:], rewrapped.Data[len(rewrapped.Data)-20:])
+	plaintext, err = rotated.Open(rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "940217450216", string(plaintext))
+	assert.Equal(t, old.Index([]byte("940217450216")), rotated.Index([]byte("940217450216")))
+
+	_, ok, err = rotated.Rewrap(rewrapped)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = old.Open(rewrapped)
+	assert.ErrorIs(t, err, envelope.ErrUnknownKey)
+	// a value cannot claim to be wrapped by another key
+	_, err = rotated.Open(envelope.Sealed{KeyID: "k2", Data: sealed.Data})
+	assert.Error(t, err)
+
+	tampered := append([]byte{}, sealed.Data...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = old.Open(envelope.Sealed{KeyID: "k1", Data: tampered})
+	assert.Error(t, err)
+	_, err = old.Open(envelope.Sealed{KeyID: "k1", Data: []byte{1, 2, 3}})
+	assert.Error(t, err)
+}
+
+func TestNewKeyring(t *testing.T) {
+	_, err := envelope.NewKeyring(map[string][]byte{"k1": key(1)}, "k2", key(9))
+	assert.EqualError(t, err, `envelope: active master key "k2" is not configured`)
+	_, err = envelope.NewKeyring(map[string][]byte{"k1": key(1)[:16]}, "k1", key(9))
+	assert.EqualError(t, err, `envelope: master key "k1" must be 32 bytes, got 16`)
+	_, err = envelope.NewKeyring(map[string][]byte{"k1": key(1)}, "k1", []byte("short"))
+	assert.EqualError(t, err, "envelope: index key must be at least 32 bytes, got 5")
+}
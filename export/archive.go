@@ -0,0 +1,144 @@
+// Package export writes the personal data of a user as a JSON document or as
+// a ZIP archive with one CSV file per section.
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"transaction-service/domain"
+)
+
+// FileName is the name offered for the download of data in format.
+func FileName(data *domain.DataExport, format string) string {
+	return fmt.Sprintf("user-%d-%s.%s", data.Profile.ID, data.GeneratedAt.Format("20060102"), format)
+}
+
+// WriteJSON writes data as an indented JSON document.
+func WriteJSON(w io.Writer, data *domain.DataExport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// WriteZIP writes data as a ZIP archive of CSV files.
+func WriteZIP(w io.Writer, data *domain.DataExport) error {
+	archive := zip.NewWriter(w)
+	for _, table := range tables(data) {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: table.name, Method: zip.Deflate, Modified: data.GeneratedAt})
+		if err != nil {
+			return err
+		}
+		out := csv.NewWriter(f)
+		if err := out.Write(table.header); err != nil {
+			return err
+		}
+		for _, row := range table.rows {
+			for i := range row {
+				row[i] = escape(row[i])
+			}
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+type table struct {
+	name   string
+	header []string
+	rows   [][]string
+}
+
+func tables(data *domain.DataExport) []table {
+	p := data.Profile
+	profile := table{name: "profile.csv", header: []string{"field", "value"}, rows: [][]string{
+		{"id", id(p.ID)},
+		{"iin", p.IIN},
+		{"username", p.Username},
+		{"email", p.Email},
+		{"role", p.Role},
+		{"account_type", p.AccountType},
+		{"status", p.Status},
+		{"register_date", p.RegisterDate},
+		{"password_changed_at", timestamp(p.PasswordChangedAt)},
+		{"deleted_at", ""},
+		{"generated_at", timestamp(data.GeneratedAt)},
+		{"unavailable", strings.Join(data.Unavailable, " ")},
+	}}
+	if p.DeletedAt != nil {
+		profile.rows[9][1] = timestamp(*p.DeletedAt)
+	}
+
+	companies := table{name: "companies.csv", header: []string{"company_id", "bin", "name", "registered_at", "role"}}
+	for _, m := range data.Companies {
+		companies.rows = append(companies.rows, []string{id(m.Company.ID), m.Company.BIN, m.Company.Name, m.Company.RegisteredAt.Format("2006-01"), m.Role})
+	}
+
+	status := table{name: "status_history.csv", header: []string{"changed_at", "from", "to", "actor_id", "reason"}}
+	for _, c := range data.StatusHistory {
+		status.rows = append(status.rows, []string{timestamp(c.ChangedAt), c.From, c.To, id(c.ActorID), c.Reason})
+	}
+
+	sessions := table{name: "sessions.csv", header: []string{"user_id", "actor_id", "expires_in"}}
+	for _, s := range data.Sessions {
+		sessions.rows = append(sessions.rows, []string{id(s.UserID), id(s.ActorID), s.TTL.Round(time.Second).String()})
+	}
+
+	accounts := table{name: "accounts.csv", header: []string{"number", "balance", "register_date", "last_transaction"}}
+	for _, a := range data.Accounts {
+		accounts.rows = append(accounts.rows, []string{a.Number, strconv.FormatInt(a.Balance, 10), a.RegisterDate, a.LastTransaction})
+	}
+
+	return []table{
+		profile, companies, status, sessions,
+		events("login_history.csv", data.LoginHistory),
+		events("audit_events.csv", data.AuditEvents),
+		accounts,
+	}
+}
+
+func events(name string, events []domain.AuditEvent) table {
+	t := table{name: name, header: []string{"id", "created_at", "action", "actor_id", "target_id", "ip", "user_agent", "details"}}
+	for _, e := range events {
+		t.rows = append(t.rows, []string{id(e.ID), timestamp(e.CreatedAt), e.Action, id(e.ActorID), id(e.TargetID), e.IP, e.UserAgent, e.Details})
+	}
+	return t
+}
+
+// id leaves the unknown IDs empty.
+func id(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// escape keeps spreadsheets from evaluating a cell as a formula, usernames
+// and user agents are chosen by the user. Negative numbers are kept as they are.
+func escape(cell string) string {
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,67 @@
+package export_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"transaction-service/domain"
+	"transaction-service/export"
+)
+
+func sample() *domain.DataExport {
+	return &domain.DataExport{
+		GeneratedAt: time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC),
+		Profile:     domain.ExportProfile{ID: 7, IIN: "940217450216", Username: "=cmd|' /C calc'!A0", Status: domain.StatusActive},
+		LoginHistory: []domain.AuditEvent{
+			{ID: 2, Action: domain.AuditLoginSuccess, ActorID: 7, TargetID: 7, IP: "10.0.0.7", CreatedAt: time.Date(2022, 5, 1, 8, 0, 0, 0, time.UTC)},
+		},
+		Accounts:    []domain.Accounts{{Number: "KZ01", Balance: -500}},
+		Unavailable: []string{"sessions"},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteJSON(&buf, sample()))
+
+	var decoded domain.DataExport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, sample().Profile, decoded.Profile)
+	assert.NotContains(t, buf.String(), "password\"")
+	assert.Equal(t, "user-7-20220601.zip", export.FileName(sample(), domain.ExportZIP))
+}
+
+func TestWriteZIP(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteZIP(&buf, sample()))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string][][]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = csv.NewReader(r).ReadAll()
+		require.NoError(t, err)
+		r.Close()
+	}
+	assert.Len(t, files, 7)
+
+	profile := files["profile.csv"]
+	assert.Equal(t, []string{"field", "value"}, profile[0])
+	assert.Contains(t, profile, []string{"username", "'=cmd|' /C calc'!A0"})
+	assert.Contains(t, profile, []string{"unavailable", "sessions"})
+	assert.Equal(t, [][]string{
+		{"id", "created_at", "action", "actor_id", "target_id", "ip", "user_agent", "details"},
+		{"2", "2022-05-01T08:00:00Z", "login.success", "7", "7", "10.0.0.7", "", ""},
+	}, files["login_history.csv"])
+	assert.Equal(t, []string{"KZ01", "-500", "", ""}, files["accounts.csv"][1])
+	assert.Len(t, files["companies.csv"], 1)
+}
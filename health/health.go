@@ -0,0 +1,211 @@
+// Package health serves the liveness and readiness probes. Readiness runs the
+// registered dependency checks, each with its own timeout, and caches the
+// result briefly so frequent probes do not load postgres or redis. The checks
+// do not run under the probe's context, a probe that goes away neither cuts
+// them short nor gets its cancellation cached.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	StatusDown        = "down"
+	StatusStarting    = "starting"
+	StatusDraining    = "draining"
+)
+
+type check struct {
+	name     string
+	timeout  time.Duration
+	critical bool
+	fn       func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status    string                 `json:"status"`
+	CheckedAt time.Time              `json:"checkedAt"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+type Health struct {
+	cacheTTL time.Duration
+	// serving reports false once the server starts draining.
+	serving func() bool
+	started int32
+
+	mu     sync.Mutex
+	checks []check
+	last   *Report
+	// running is the refresh the concurrent probes wait for, nil when none is
+	running *refresh
+}
+
+type refresh struct {
+	done   chan struct{}
+	report Report
+}
+
+func New(cacheTTL time.Duration, serving func() bool) *Health {
+	return &Health{cacheTTL: cacheTTL, serving: serving}
+}
+
+// MarkStarted ends the starting state once migrations and other startup work
+// that waited for the dependencies are done.
+func (h *Health) MarkStarted() {
+	atomic.StoreInt32(&h.started, 1)
+}
+
+// Register adds a dependency check. A failing critical check makes the instance
+// unavailable, a failing optional one only degrades it.
+func (h *Health) Register(name string, timeout time.Duration, critical bool, fn func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, check{name, timeout, critical, fn})
+	h.last = nil
+	h.running = nil
+}
+
+// Liveness only tells the process is running, it never checks dependencies so
+// an outage does not get every instance restarted.
+func (h *Health) Liveness(e echo.Context) error {
+	return e.JSON(http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+func (h *Health) Readiness(e echo.Context) error {
+	report := h.Check(e.Request().Context())
+	code := http.StatusOK
+	if report.Status != StatusOK && report.Status != StatusDegraded {
+		code = http.StatusServiceUnavailable
+	}
+	return e.JSON(code, report)
+}
+
+// Check returns the cached report or waits for every check to run
+// concurrently. Concurrent calls share one run. When ctx is done first the
+// report is unavailable and the run goes on to fill the cache.
+func (h *Health) Check(ctx context.Context) Report {
+	h.mu.Lock()
+	if h.last != nil && time.Since(h.last.CheckedAt) < h.cacheTTL {
+		report := *h.last
+		h.mu.Unlock()
+		return h.withServing(report)
+	}
+	r := h.running
+	if r == nil {
+		r = &refresh{done: make(chan struct{})}
+		h.running = r
+		go h.refresh(r, h.checks)
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return h.withServing(r.report)
+	case <-ctx.Done():
+		return h.withServing(Report{Status: StatusUnavailable, CheckedAt: time.Now(), Checks: map[string]CheckResult{}})
+	}
+}
+
+// refresh runs checks and caches the report unless a check was registered
+// meanwhile.
+func (h *Health) refresh(r *refresh, checks []check) {
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = run(context.Background(), c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, CheckedAt: time.Now(), Checks: map[string]CheckResult{}}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status == StatusOK {
+			continue
+		}
+		if c.critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	h.mu.Lock()
+	if h.running == r {
+		h.last = &report
+		h.running = nil
+	}
+	h.mu.Unlock()
+	r.report = report
+	close(r.done)
+}
+
+// withServing reports draining or starting ahead of the dependency status.
+func (h *Health) withServing(report Report) Report {
+	switch {
+	case h.serving != nil && !h.serving():
+		report.Status = StatusDraining
+	case atomic.LoadInt32(&h.started) == 0:
+		report.Status = StatusStarting
+	}
+	return report
+}
+
+func run(ctx context.Context, c check) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start).Round(time.Millisecond).String()
+		if r := recover(); r != nil {
+			result.Status, result.Error = StatusDown, fmt.Sprint(r)
+		}
+	}()
+
+	result = CheckResult{Status: StatusOK, Critical: c.critical}
+	if err := c.fn(ctx); err != nil {
+		result.Status, result.Error = StatusDown, err.Error()
+	}
+	return result
+}
+
+// HTTPCheck reports whether url answers without a server error.
+func HTTPCheck(client *http.Client, url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%s answered %s", url, resp.Status)
+		}
+		return nil
+	}
+}
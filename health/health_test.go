@@ -0,0 +1,105 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"transaction-service/health"
+)
+
+func readiness(t *testing.T, h *health.Health) (int, health.Report) {
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	assert.NoError(t, h.Readiness(e.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec)))
+
+	report := health.Report{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		h := health.New(time.Minute, func() bool { return true })
+		calls := 0
+		h.Register("postgres", time.Second, true, func(context.Context) error {
+			calls++
+			return nil
+		})
+		h.Register("transaction-service", time.Second, false, func(context.Context) error { return errors.New("connection refused") })
+
+		code, report := readiness(t, h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, health.StatusStarting, report.Status)
+
+		h.MarkStarted()
+		code, report = readiness(t, h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, health.StatusDegraded, report.Status)
+		assert.Equal(t, health.StatusOK, report.Checks["postgres"].Status)
+		assert.Equal(t, "connection refused", report.Checks["transaction-service"].Error)
+		// the second probe is answered from the cache
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		serving := true
+		h := health.New(0, func() bool { return serving })
+		h.MarkStarted()
+		h.Register("redis", 10*time.Millisecond, true, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		code, report := readiness(t, h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, health.StatusUnavailable, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["redis"].Error)
+
+		serving = false
+		_, report = readiness(t, h)
+		assert.Equal(t, health.StatusDraining, report.Status)
+	})
+	t.Run("error-cancelled", func(t *testing.T) {
+		h := health.New(time.Minute, nil)
+		h.MarkStarted()
+		release := make(chan struct{})
+		calls := 0
+		h.Register("postgres", time.Second, true, func(ctx context.Context) error {
+			calls++
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		// the probe gives up, the check it started is not cancelled with it
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Equal(t, health.StatusUnavailable, h.Check(ctx).Status)
+		close(release)
+
+		report := h.Check(context.Background())
+		assert.Equal(t, health.StatusOK, report.Status)
+		assert.Equal(t, health.StatusOK, report.Checks["postgres"].Status)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestLiveness(t *testing.T) {
+	h := health.New(time.Second, nil)
+	h.Register("postgres", time.Second, true, func(context.Context) error { return errors.New("down") })
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	assert.NoError(t, h.Liveness(e.NewContext(httptest.NewRequest(http.MethodGet, "/healthz", nil), rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,121 @@
+// Package httperror renders the errors returned by handlers. Handlers return
+// domain errors and this package picks the status code, logs the cause and
+// renders error.html or a JSON body with the stable error code.
+package httperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"transaction-service/domain"
+	"transaction-service/logging"
+	"transaction-service/validation"
+
+	"github.com/labstack/echo/v4"
+)
+
+// internalMessage is shown instead of the message of internal errors.
+const internalMessage = "Unexpected error. Please try again in several minutes"
+
+const formatKey = "httperror.format"
+
+// Response is the JSON body of an error.
+type Response struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	// Fields lists every invalid field of a validation error.
+	Fields validation.Errors `json:"fields,omitempty"`
+}
+
+// Status returns the status code of an error kind.
+func Status(kind domain.Kind) int {
+	switch kind {
+	case domain.KindNotFound:
+		return http.StatusNotFound
+	case domain.KindConflict:
+		return http.StatusConflict
+	case domain.KindUnauthorized:
+		return http.StatusUnauthorized
+	case domain.KindForbidden:
+		return http.StatusForbidden
+	case domain.KindValidation:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// JSON renders the errors of a route as JSON whatever the Accept header says.
+func JSON(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set(formatKey, echo.MIMEApplicationJSON)
+		return next(c)
+	}
+}
+
+// Handler is the echo HTTPErrorHandler of the service.
+func Handler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, resp, cause := describe(err)
+	resp.RequestID = logging.RequestID(c.Request().Context())
+	if fields, ok := validation.As(err); ok {
+		resp.Fields = fields.Localize(validation.Language(c.Request().Header.Get("Accept-Language")))
+	}
+
+	logger := logging.From(c)
+	event := logger.Warn()
+	if status >= http.StatusInternalServerError {
+		event = logger.Error()
+	}
+	event.Err(cause).Str("code", resp.Code).Int("status", status).Msg(resp.Message)
+
+	if status >= http.StatusInternalServerError {
+		resp.Message = internalMessage
+	}
+
+	var renderErr error
+	switch {
+	case c.Request().Method == http.MethodHead:
+		renderErr = c.NoContent(status)
+	case wantsJSON(c):
+		// replaces the text/html default set for the pages
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		renderErr = c.JSON(status, resp)
+	default:
+		renderErr = c.Render(status, "error.html", resp.Message)
+	}
+	if renderErr != nil {
+		logger.Err(renderErr).Msg("cannot render error")
+	}
+}
+
+// describe returns the status, the client response and the logged cause of err.
+func describe(err error) (int, Response, error) {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		// raised by echo itself or its middleware, e.g. unknown routes
+		message := http.StatusText(httpErr.Code)
+		if m, ok := httpErr.Message.(string); ok {
+			message = m
+		} else if httpErr.Message != nil {
+			message = fmt.Sprint(httpErr.Message)
+		}
+		code := strings.ReplaceAll(strings.ToLower(http.StatusText(httpErr.Code)), " ", "_")
+		return httpErr.Code, Response{Code: code, Message: message}, httpErr.Internal
+	}
+
+	derr := domain.AsError(err)
+	return Status(derr.Kind), Response{Code: derr.Code, Message: derr.Message}, derr.Err
+}
+
+func wantsJSON(c echo.Context) bool {
+	if format, _ := c.Get(formatKey).(string); format == echo.MIMEApplicationJSON {
+		return true
+	}
+	accept := c.Request().Header.Get(echo.HeaderAccept)
+	return strings.Contains(accept, echo.MIMEApplicationJSON) && !strings.Contains(accept, echo.MIMETextHTML)
+}
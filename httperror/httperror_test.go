@@ -0,0 +1,116 @@
+package httperror_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"transaction-service/domain"
+	"transaction-service/httperror"
+	"transaction-service/validation"
+)
+
+type renderer struct{}
+
+func (renderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	_, err := fmt.Fprintf(w, "%s: %v", name, data)
+	return err
+}
+
+func serve(accept string, err error, middleware ...echo.MiddlewareFunc) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Renderer = renderer{}
+	e.HTTPErrorHandler = httperror.Handler
+	e.GET("/user/info/:id", func(echo.Context) error { return err }, middleware...)
+
+	req := httptest.NewRequest(http.MethodGet, "/user/info/7", nil)
+	req.Header.Set(echo.HeaderAccept, accept)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		err := fmt.Errorf("get user info: %w", domain.NotFound(domain.CodeUserNotFound, "user not found", domain.ErrNotFound))
+
+		rec := serve("text/html", err)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "error.html: user not found", rec.Body.String())
+
+		rec = serve(echo.MIMEApplicationJSON, err)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		resp := httperror.Response{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, domain.CodeUserNotFound, resp.Code)
+		assert.Equal(t, "user not found", resp.Message)
+
+		rec = serve("text/html", domain.ErrUnauthenticated, httperror.JSON)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, domain.CodeUnauthenticated, resp.Code)
+	})
+	t.Run("validation", func(t *testing.T) {
+		var fields validation.Errors
+		fields.Add("iin", validation.NewFieldError("iin", validation.CodeIINLength))
+		rec := serve(echo.MIMEApplicationJSON, domain.Validation(domain.CodeInvalidInput, "invalid registration form", fields.Err()))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		resp := httperror.Response{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, domain.CodeInvalidInput, resp.Code)
+		assert.Len(t, resp.Fields, 1)
+		assert.Equal(t, validation.CodeIINLength, resp.Fields[0].Code)
+	})
+	t.Run("error-internal", func(t *testing.T) {
+		// the cause of internal errors never reaches the client
+		rec := serve(echo.MIMEApplicationJSON, errors.New("pq: password authentication failed"))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "password authentication")
+		resp := httperror.Response{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, domain.CodeInternal, resp.Code)
+
+		rec = serve("text/html", domain.Internal("cannot load user", errors.New("connection refused")))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "cannot load user")
+	})
+	t.Run("error-echo", func(t *testing.T) {
+		rec := serve(echo.MIMEApplicationJSON, echo.ErrMethodNotAllowed)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		resp := httperror.Response{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "method_not_allowed", resp.Code)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	cases := map[domain.Kind]int{
+		domain.KindNotFound:     http.StatusNotFound,
+		domain.KindConflict:     http.StatusConflict,
+		domain.KindUnauthorized: http.StatusUnauthorized,
+		domain.KindForbidden:    http.StatusForbidden,
+		domain.KindValidation:   http.StatusBadRequest,
+		domain.KindInternal:     http.StatusInternalServerError,
+	}
+	for kind, status := range cases {
+		assert.Equal(t, status, httperror.Status(kind), kind)
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("signin: %w", domain.Forbidden(domain.CodeAccountLocked, "account is locked", nil))
+
+	assert.True(t, errors.Is(err, &domain.Error{Code: domain.CodeAccountLocked}))
+	assert.False(t, errors.Is(err, &domain.Error{Code: domain.CodeUserNotFound}))
+	assert.Equal(t, domain.KindForbidden, domain.KindOf(err))
+	assert.Equal(t, domain.KindInternal, domain.KindOf(errors.New("boom")))
+	assert.Equal(t, domain.Kind(""), domain.KindOf(nil))
+}
@@ -0,0 +1,92 @@
+package iin
+
+import (
+	"time"
+	"transaction-service/validation"
+)
+
+// EntityType is the kind of organization a BIN is issued to.
+type EntityType string
+
+const (
+	Resident          EntityType = "resident"
+	NonResident       EntityType = "non_resident"
+	JointEntrepreneur EntityType = "joint_entrepreneur"
+)
+
+// Division tells a head office from its branches and representative offices.
+type Division string
+
+const (
+	HeadOffice     Division = "head_office"
+	Branch         Division = "branch"
+	Representative Division = "representative_office"
+	Farm           Division = "farm"
+)
+
+// entityTypes are indexed by the 5th digit of a BIN, divisions by the 6th.
+var (
+	entityTypes = map[int]EntityType{4: Resident, 5: NonResident, 6: JointEntrepreneur}
+	divisions   = []Division{HeadOffice, Branch, Representative, Farm}
+)
+
+// BIN is a parsed and valid business identification number, the zero value
+// is not valid.
+//
+// A BIN is YYMM of the registration, a digit for the entity type, a digit for
+// the division, a five digit serial and a check digit computed as for an IIN.
+type BIN struct {
+	value      string
+	registered time.Time
+	entityType EntityType
+	division   Division
+}
+
+// ParseBIN validates s and extracts what it encodes. The error is a
+// *validation.FieldError of the bin field.
+func ParseBIN(s string) (BIN, error) {
+	if len(s) != Length {
+		return BIN{}, validation.NewFieldError("bin", validation.CodeBINLength)
+	}
+	digits, ok := toDigits(s)
+	if !ok {
+		return BIN{}, validation.NewFieldError("bin", validation.CodeBINDigits)
+	}
+
+	entityType, ok := entityTypes[digits[4]]
+	if !ok || digits[5] >= len(divisions) {
+		return BIN{}, validation.NewFieldError("bin", validation.CodeBINEntity)
+	}
+
+	month := time.Month(digits[2]*10 + digits[3])
+	if month < time.January || month > time.December {
+		return BIN{}, validation.NewFieldError("bin", validation.CodeBINDate)
+	}
+	// the century is not encoded, BINs are issued since the nineties
+	registered := time.Date(2000+digits[0]*10+digits[1], month, 1, 0, 0, 0, 0, time.UTC)
+	if registered.After(time.Now()) {
+		registered = registered.AddDate(-100, 0, 0)
+	}
+
+	if check, ok := checkDigit(digits); !ok || check != digits[11] {
+		return BIN{}, validation.NewFieldError("bin", validation.CodeBINChecksum)
+	}
+	return BIN{value: s, registered: registered, entityType: entityType, division: divisions[digits[5]]}, nil
+}
+
+func (b BIN) String() string {
+	return b.value
+}
+
+// Registered is the first day of the month of the registration.
+func (b BIN) Registered() time.Time {
+	return b.registered
+}
+
+func (b BIN) EntityType() EntityType {
+	return b.entityType
+}
+
+func (b BIN) Division() Division {
+	return b.division
+}
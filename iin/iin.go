@@ -0,0 +1,144 @@
+// Package iin parses the individual and business identification numbers of
+// Kazakhstan.
+//
+// An IIN is YYMMDD, a digit encoding the century and the gender, a four digit
+// serial and a check digit.
+package iin
+
+import (
+	"strings"
+	"time"
+	"transaction-service/validation"
+)
+
+// Length is the number of digits of an IIN and of a BIN.
+const Length = 12
+
+type Gender string
+
+const (
+	Male   Gender = "male"
+	Female Gender = "female"
+)
+
+// IIN is a parsed and valid individual identification number, the zero value
+// is not valid.
+type IIN struct {
+	value     string
+	birthDate time.Time
+	gender    Gender
+}
+
+// Parse validates s and extracts what it encodes. The error is a
+// *validation.FieldError of the iin field.
+func Parse(s string) (IIN, error) {
+	if len(s) != Length {
+		return IIN{}, validation.NewFieldError("iin", validation.CodeIINLength)
+	}
+	digits, ok := toDigits(s)
+	if !ok {
+		return IIN{}, validation.NewFieldError("iin", validation.CodeIINDigits)
+	}
+
+	// 1 and 2 are men and women born in the 19th century, 3 and 4 in the 20th, 5 and 6 in the 21st
+	code := digits[6]
+	if code < 1 || code > 6 {
+		return IIN{}, validation.NewFieldError("iin", validation.CodeIINCentury)
+	}
+	gender := Male
+	if code%2 == 0 {
+		gender = Female
+	}
+	year := 1800 + (code-1)/2*100 + digits[0]*10 + digits[1]
+	month := time.Month(digits[2]*10 + digits[3])
+	day := digits[4]*10 + digits[5]
+	birthDate := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// time.Date normalizes dates such as February 30th
+	if birthDate.Month() != month || birthDate.Day() != day || birthDate.After(time.Now()) {
+		return IIN{}, validation.NewFieldError("iin", validation.CodeIINDate)
+	}
+
+	if check, ok := checkDigit(digits); !ok || check != digits[11] {
+		return IIN{}, validation.NewFieldError("iin", validation.CodeIINChecksum)
+	}
+	return IIN{value: s, birthDate: birthDate, gender: gender}, nil
+}
+
+func (i IIN) String() string {
+	return i.value
+}
+
+// BirthDate is the date of birth at midnight UTC.
+func (i IIN) BirthDate() time.Time {
+	return i.birthDate
+}
+
+// Century is the century of birth, 20 for someone born in 1999.
+func (i IIN) Century() int {
+	return i.birthDate.Year()/100 + 1
+}
+
+func (i IIN) Gender() Gender {
+	return i.gender
+}
+
+// AgeAt returns the age in full years at now.
+func (i IIN) AgeAt(now time.Time) int {
+	age := now.Year() - i.birthDate.Year()
+	if !birthdayPassed(i.birthDate, now) {
+		age--
+	}
+	return age
+}
+
+// Age returns the age in full years today.
+func (i IIN) Age() int {
+	return i.AgeAt(time.Now())
+}
+
+// Mask hides the middle of an IIN or a BIN for display, 940217450216 becomes
+// 9402******16. Short values are hidden entirely.
+func Mask(s string) string {
+	if len(s) <= 6 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:4] + strings.Repeat("*", len(s)-6) + s[len(s)-2:]
+}
+
+func birthdayPassed(birth, now time.Time) bool {
+	if now.Month() != birth.Month() {
+		return now.Month() > birth.Month()
+	}
+	return now.Day() >= birth.Day()
+}
+
+// checkDigit computes the check digit of the first 11 digits, ok is false
+// for the numbers that have none and are never issued.
+func checkDigit(digits []int) (int, bool) {
+	sum := 0
+	for i := 0; i < 11; i++ {
+		sum += (i + 1) * digits[i]
+	}
+	if check := sum % 11; check != 10 {
+		return check, true
+	}
+
+	// the second pass weights are 3 to 11 then 1 and 2
+	sum = 0
+	for i := 0; i < 11; i++ {
+		sum += ((i+2)%11 + 1) * digits[i]
+	}
+	check := sum % 11
+	return check, check != 10
+}
+
+func toDigits(s string) ([]int, bool) {
+	digits := make([]int, len(s))
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return nil, false
+		}
+		digits[i] = int(r - '0')
+	}
+	return digits, true
+}
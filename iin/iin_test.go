@@ -0,0 +1,111 @@
+package iin_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"transaction-service/iin"
+	"transaction-service/validation"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		id, err := iin.Parse("990824351277")
+		assert.NoError(t, err)
+		assert.Equal(t, "990824351277", id.String())
+		assert.Equal(t, time.Date(1999, time.August, 24, 0, 0, 0, 0, time.UTC), id.BirthDate())
+		assert.Equal(t, 20, id.Century())
+		assert.Equal(t, iin.Male, id.Gender())
+
+		id, err = iin.Parse("940217450216")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(1994, time.February, 17, 0, 0, 0, 0, time.UTC), id.BirthDate())
+		assert.Equal(t, iin.Female, id.Gender())
+
+		// the check digit of this one comes from the second pass
+		id, err = iin.Parse("000101500206")
+		assert.NoError(t, err)
+		assert.Equal(t, 21, id.Century())
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		cases := map[string]string{
+			"99082435127":  validation.CodeIINLength,
+			"99082435127x": validation.CodeIINDigits,
+			"990824751277": validation.CodeIINCentury,
+			"990230351277": validation.CodeIINDate,
+			"990824351276": validation.CodeIINChecksum,
+			"991231651270": validation.CodeIINDate,
+		}
+		for s, code := range cases {
+			_, err := iin.Parse(s)
+			var fe *validation.FieldError
+			if assert.True(t, errors.As(err, &fe), s) {
+				assert.Equal(t, code, fe.Code, s)
+			}
+		}
+	})
+}
+
+func TestAgeAt(t *testing.T) {
+	id, err := iin.Parse("990824351277")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 17, id.AgeAt(time.Date(2017, time.August, 23, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 18, id.AgeAt(time.Date(2017, time.August, 24, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 22, id.AgeAt(time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "9402******16", iin.Mask("940217450216"))
+	assert.Equal(t, "0806******24", iin.Mask("080640000124"))
+	assert.Equal(t, "***", iin.Mask("940"))
+	assert.Equal(t, "", iin.Mask(""))
+}
+
+func TestParseBIN(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		id, err := iin.ParseBIN("080640000124")
+		assert.NoError(t, err)
+		assert.Equal(t, "080640000124", id.String())
+		assert.Equal(t, time.Date(2008, time.June, 1, 0, 0, 0, 0, time.UTC), id.Registered())
+		assert.Equal(t, iin.Resident, id.EntityType())
+		assert.Equal(t, iin.HeadOffice, id.Division())
+
+		id, err = iin.ParseBIN("981141003454")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(1998, time.November, 1, 0, 0, 0, 0, time.UTC), id.Registered())
+		assert.Equal(t, iin.Branch, id.Division())
+
+		id, err = iin.ParseBIN("210550007776")
+		assert.NoError(t, err)
+		assert.Equal(t, iin.NonResident, id.EntityType())
+
+		id, err = iin.ParseBIN("101063000107")
+		assert.NoError(t, err)
+		assert.Equal(t, iin.JointEntrepreneur, id.EntityType())
+		assert.Equal(t, iin.Farm, id.Division())
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		cases := map[string]string{
+			"08064000012":  validation.CodeBINLength,
+			"08064000012x": validation.CodeBINDigits,
+			"080630000124": validation.CodeBINEntity,
+			"080644000124": validation.CodeBINEntity,
+			"081340000124": validation.CodeBINDate,
+			"080640000125": validation.CodeBINChecksum,
+			// an IIN is not a BIN
+			"990824351277": validation.CodeBINEntity,
+		}
+		for s, code := range cases {
+			_, err := iin.ParseBIN(s)
+			var fe *validation.FieldError
+			if assert.True(t, errors.As(err, &fe), s) {
+				assert.Equal(t, code, fe.Code, s)
+				assert.Equal(t, "bin", fe.Field, s)
+			}
+		}
+	})
+}
@@ -0,0 +1,158 @@
+// Package lifecycle runs the server until a shutdown signal and then drains it:
+// readiness turns false, in-flight requests finish, background workers stop and
+// the connections are closed, all within a deadline.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type Manager struct {
+	drainDelay time.Duration
+	timeout    time.Duration
+
+	ready int32
+
+	mu    sync.Mutex
+	hooks []hook
+
+	workers       sync.WaitGroup
+	workerCtx     context.Context
+	cancelWorkers context.CancelFunc
+
+	// failed carries the first error a worker gave up with
+	failed chan error
+}
+
+// NewManager waits drainDelay after readiness turns false before shutting down,
+// so load balancers stop routing new requests first, and gives the shutdown
+// hooks timeout to finish.
+func NewManager(drainDelay, timeout time.Duration) *Manager {
+	m := &Manager{drainDelay: drainDelay, timeout: timeout, failed: make(chan error, 1)}
+	m.workerCtx, m.cancelWorkers = context.WithCancel(context.Background())
+	return m
+}
+
+// OnShutdown registers fn to run on shutdown. Hooks run in reverse order of
+// registration, so resources are closed after everything that uses them.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name, fn})
+}
+
+// Go runs a background worker until StopWorkers is called.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		fn(m.workerCtx)
+		log.Debug().Str("worker", name).Msg("worker stopped")
+	}()
+}
+
+// Fail makes Run shut down and return err, for a worker that cannot go on. It
+// does not block, the errors after the first one are dropped.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failed <- err:
+	default:
+		log.Err(err).Msg("worker failed during shutdown")
+	}
+}
+
+// StopWorkers cancels the workers started with Go and waits for them, register it
+// as a hook after the resources the workers use.
+func (m *Manager) StopWorkers(ctx context.Context) error {
+	m.cancelWorkers()
+
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&m.ready, v)
+}
+
+func (m *Manager) Ready() bool {
+	return atomic.LoadInt32(&m.ready) == 1
+}
+
+// Run calls serve and blocks until ctx is done, serve fails or a worker calls
+// Fail, then shuts down.
+func (m *Manager) Run(ctx context.Context, serve func() error) error {
+	served := make(chan error, 1)
+	go func() { served <- serve() }()
+	m.SetReady(true)
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		log.Info().Dur("drain_delay", m.drainDelay).Msg("shutdown requested, draining")
+		m.SetReady(false)
+		select {
+		case <-time.After(m.drainDelay):
+		case serveErr = <-served:
+		}
+	case serveErr = <-served:
+		m.SetReady(false)
+	case serveErr = <-m.failed:
+		m.SetReady(false)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	err := m.Shutdown(shutdownCtx)
+
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
+
+// Shutdown runs the hooks, it returns the first error.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var first error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := h.fn(ctx); err != nil {
+			log.Err(err).Str("hook", h.name).Msg("shutdown error")
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		log.Info().Str("hook", h.name).Msg("shut down")
+	}
+	return first
+}
@@ -0,0 +1,95 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"transaction-service/lifecycle"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		m := lifecycle.NewManager(10*time.Millisecond, time.Second)
+
+		var order []string
+		m.OnShutdown("postgres", func(context.Context) error {
+			order = append(order, "postgres")
+			return nil
+		})
+		m.OnShutdown("workers", m.StopWorkers)
+		stopped := make(chan struct{})
+		m.Go("ticker", func(ctx context.Context) {
+			<-ctx.Done()
+			order = append(order, "worker")
+			close(stopped)
+		})
+
+		served := make(chan struct{})
+		m.OnShutdown("http", func(context.Context) error {
+			assert.False(t, m.Ready())
+			order = append(order, "http")
+			close(served)
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- m.Run(ctx, func() error {
+				<-served
+				return http.ErrServerClosed
+			})
+		}()
+
+		assert.Eventually(t, m.Ready, time.Second, time.Millisecond)
+		cancel()
+		assert.NoError(t, <-done)
+		<-stopped
+		assert.Equal(t, []string{"http", "worker", "postgres"}, order)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		m := lifecycle.NewManager(time.Hour, time.Second)
+		closed := false
+		m.OnShutdown("redis", func(context.Context) error {
+			closed = true
+			return nil
+		})
+
+		listenErr := errors.New("address already in use")
+		err := m.Run(context.Background(), func() error { return listenErr })
+
+		assert.Equal(t, listenErr, err)
+		assert.True(t, closed)
+		assert.False(t, m.Ready())
+	})
+	t.Run("error-worker", func(t *testing.T) {
+		m := lifecycle.NewManager(time.Hour, time.Second)
+		closed := false
+		m.OnShutdown("postgres", func(context.Context) error {
+			closed = true
+			return nil
+		})
+		served := make(chan struct{})
+		m.OnShutdown("http", func(context.Context) error {
+			close(served)
+			return nil
+		})
+		m.OnShutdown("workers", m.StopWorkers)
+
+		migrateErr := errors.New("migrate error")
+		m.Go("startup", func(context.Context) { m.Fail(migrateErr) })
+		err := m.Run(context.Background(), func() error {
+			<-served
+			return http.ErrServerClosed
+		})
+
+		assert.Equal(t, migrateErr, err)
+		assert.True(t, closed)
+		assert.False(t, m.Ready())
+	})
+}
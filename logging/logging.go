@@ -0,0 +1,134 @@
+// Package logging attaches a request scoped zerolog logger to every request
+// and writes the access log.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID carries the request id in both directions.
+const HeaderRequestID = echo.HeaderXRequestID
+
+// maxRequestIDLength bounds the incoming ids that are trusted as is.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// Middleware assigns the request id, honouring a valid incoming X-Request-ID,
+// attaches a logger carrying the id, route and client IP to the request
+// context and logs one line per request once it has been handled.
+func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		req := c.Request()
+
+		id := req.Header.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Response().Header().Set(HeaderRequestID, id)
+
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+		fields := log.With().
+			Str("request_id", id).
+			Str("route", route).
+			Str("ip", c.RealIP())
+		if span := trace.SpanContextFromContext(req.Context()); span.IsValid() {
+			fields = fields.Str("trace_id", span.TraceID().String())
+		}
+		logger := fields.Logger()
+
+		// the context keeps a pointer to logger, SetUser updates it in place
+		ctx := context.WithValue(req.Context(), requestIDKey{}, id)
+		c.SetRequest(req.WithContext(logger.WithContext(ctx)))
+
+		if err := next(c); err != nil {
+			// the error handler writes the response and logs the cause, so the
+			// logged status is the one sent
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		event := logger.Info()
+		if status >= http.StatusInternalServerError {
+			event = logger.Error()
+		}
+		event.
+			Str("method", req.Method).
+			Str("path", req.URL.Path).
+			Int("status", status).
+			Int64("bytes", c.Response().Size).
+			Dur("latency", time.Since(start)).
+			Msg("request")
+		return nil
+	}
+}
+
+// Ctx returns the logger of the request ctx belongs to, or the global logger
+// outside of a request.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+		return logger
+	}
+	return &log.Logger
+}
+
+// From returns the logger of the request.
+func From(c echo.Context) *zerolog.Logger {
+	return Ctx(c.Request().Context())
+}
+
+// SetUser adds the authenticated user to the request logger, the access log
+// line included. actorID is zero unless the request is impersonated.
+func SetUser(c echo.Context, userID, actorID int64) {
+	logger := zerolog.Ctx(c.Request().Context())
+	if logger.GetLevel() == zerolog.Disabled {
+		return
+	}
+	logger.UpdateContext(func(l zerolog.Context) zerolog.Context {
+		l = l.Int64("user_id", userID)
+		if actorID != 0 {
+			l = l.Int64("actor_id", actorID)
+		}
+		return l
+	})
+}
+
+// RequestID returns the id of the request ctx belongs to.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		// printable ASCII without spaces, the id ends up in headers and log lines
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
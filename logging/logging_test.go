@@ -0,0 +1,109 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+
+	"transaction-service/logging"
+)
+
+func serve(t *testing.T, req *http.Request, handler echo.HandlerFunc) (*httptest.ResponseRecorder, []map[string]interface{}) {
+	buf := &bytes.Buffer{}
+	previous := log.Logger
+	log.Logger = zerolog.New(buf)
+	defer func() { log.Logger = previous }()
+
+	e := echo.New()
+	e.Use(logging.Middleware)
+	e.GET("/user/info/:id", handler)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	lines := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return rec, lines
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/user/info/7", nil)
+		req.Header.Set(logging.HeaderRequestID, "req-42")
+		rec, lines := serve(t, req, func(c echo.Context) error {
+			logging.SetUser(c, 7, 0)
+			assert.Equal(t, "req-42", logging.RequestID(c.Request().Context()))
+			logging.From(c).Info().Msg("handled")
+			return c.String(http.StatusOK, "ok")
+		})
+
+		assert.Equal(t, "req-42", rec.Header().Get(logging.HeaderRequestID))
+		assert.Len(t, lines, 2)
+		for _, line := range lines {
+			assert.Equal(t, "req-42", line["request_id"])
+			assert.Equal(t, "/user/info/:id", line["route"])
+			assert.Equal(t, float64(7), line["user_id"])
+		}
+		assert.Equal(t, "handled", lines[0]["message"])
+		assert.Equal(t, float64(http.StatusOK), lines[1]["status"])
+		assert.Equal(t, "/user/info/7", lines[1]["path"])
+		assert.Contains(t, lines[1], "latency")
+	})
+	t.Run("error-invalid-request-id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/user/info/7", nil)
+		req.Header.Set(logging.HeaderRequestID, "bad id\nforged line")
+		rec, lines := serve(t, req, func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusNotFound)
+		})
+
+		id := rec.Header().Get(logging.HeaderRequestID)
+		assert.Len(t, id, 32)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Len(t, lines, 1)
+		assert.Equal(t, id, lines[0]["request_id"])
+		assert.Equal(t, float64(http.StatusNotFound), lines[0]["status"])
+		assert.NotContains(t, lines[0], "user_id")
+	})
+}
+
+func TestCtx(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.Equal(t, &log.Logger, logging.Ctx(req.Context()))
+	assert.Equal(t, "", logging.RequestID(req.Context()))
+}
+
+func TestRedact(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	token := "eyJhbGciOiJIUzI1NiJ9.eyJpZCI6N30.c2lnbmF0dXJl"
+	logger.Info().
+		Stringer("iin", logging.IIN("940217450216")).
+		Stringer("token", logging.Secret(token)).
+		Stringer("missing", logging.Secret("")).
+		Interface("fields", map[string]interface{}{"iin": logging.IIN("940217450216"), "password": logging.Secret("Secret-passw0rd")}).
+		Msg("request")
+
+	line := buf.String()
+	assert.NotContains(t, line, "940217450216")
+	assert.NotContains(t, line, token)
+	assert.NotContains(t, line, "Secret-passw0rd")
+	var decoded map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded)) {
+		assert.Equal(t, "9402******16", decoded["iin"])
+		assert.Equal(t, "[REDACTED]", decoded["token"])
+		assert.Equal(t, "", decoded["missing"])
+		assert.Equal(t, map[string]interface{}{"iin": "9402******16", "password": "[REDACTED]"}, decoded["fields"])
+	}
+}
@@ -0,0 +1,40 @@
+package logging
+
+import "transaction-service/iin"
+
+const redacted = "[REDACTED]"
+
+// IIN is an IIN as it may be logged, masked as iin.Mask does:
+//
+//	logger.Info().Stringer("iin", logging.IIN(user.IIN)).Msg("...")
+//
+// Values are redacted by their type rather than by scanning the encoded line,
+// which cannot tell an IIN from any other 12 digits nor see a token split by
+// escaping. Anything personal or secret is logged through one of these types
+// or not at all.
+type IIN string
+
+func (v IIN) String() string {
+	return iin.Mask(string(v))
+}
+
+// MarshalText masks the IIN when it is logged with Interface.
+func (v IIN) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Secret is a token, password, hash or client secret, logged only as whether
+// it was set.
+type Secret string
+
+func (v Secret) String() string {
+	if v == "" {
+		return ""
+	}
+	return redacted
+}
+
+// MarshalText redacts the secret when it is logged with Interface.
+func (v Secret) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Observe records a dependency call that started at start.
+func Observe(dependency, operation string, start time.Time, err error) {
+	outcome := "success"
+	switch {
+	case err == redis.Nil:
+		// a missing key is how redis reports a revoked or expired session
+		outcome = "miss"
+	case err != nil:
+		outcome = "error"
+	}
+	observe(dependency, operation, outcome, start)
+}
+
+func observe(dependency, operation, outcome string, start time.Time) {
+	DependencyDuration.WithLabelValues(dependency, operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+type roundTripper struct {
+	dependency string
+	next       http.RoundTripper
+}
+
+func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+
+	outcome := "success"
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		outcome = "error"
+	}
+	observe(r.dependency, req.Method, outcome, start)
+	return resp, err
+}
+
+// Transport records the calls made through next as dependency calls.
+func Transport(dependency string, next http.RoundTripper) http.RoundTripper {
+	return roundTripper{dependency: dependency, next: next}
+}
+
+// Client returns an HTTP client whose calls are recorded as dependency calls.
+func Client(dependency string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: Transport(dependency, http.DefaultTransport),
+	}
+}
+
+// poolCollector exports the pgxpool connection statistics.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired, idle, total, max, acquireCount, acquireWait, emptyAcquire *prometheus.Desc
+}
+
+// RegisterPool exports the statistics of the postgres connection pool.
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+	Registry.MustRegister(&poolCollector{
+		pool:         pool,
+		acquired:     desc("acquired_connections", "Connections currently in use."),
+		idle:         desc("idle_connections", "Idle connections in the pool."),
+		total:        desc("total_connections", "Connections open, in use or idle."),
+		max:          desc("max_connections", "Maximum size of the pool."),
+		acquireCount: desc("acquires_total", "Successful connection acquires."),
+		acquireWait:  desc("acquire_wait_seconds_total", "Time spent waiting for a connection."),
+		emptyAcquire: desc("empty_acquires_total", "Acquires that had to wait because the pool was empty."),
+	})
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{p.acquired, p.idle, p.total, p.max, p.acquireCount, p.acquireWait, p.emptyAcquire} {
+		ch <- d
+	}
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := p.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(p.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(p.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(p.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(p.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(p.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(p.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+}
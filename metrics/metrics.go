@@ -0,0 +1,110 @@
+// Package metrics defines the prometheus metrics of the service and exposes
+// them on /metrics.
+package metrics
+
+import (
+	"transaction-service/domain"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "auth"
+
+// Login outcomes.
+const (
+	LoginSuccess     = "success"
+	LoginUnknownUser = "unknown_user"
+	LoginBadPassword = "bad_password"
+	LoginInactive    = "inactive"
+	LoginError       = "error"
+)
+
+// Signup outcomes.
+const (
+	SignupSuccess  = "success"
+	SignupRejected = "rejected"
+	SignupError    = "error"
+)
+
+// SignupOutcome maps the error of a failed registration to its outcome.
+func SignupOutcome(err error) string {
+	if domain.KindOf(err) == domain.KindInternal {
+		return SignupError
+	}
+	return SignupRejected
+}
+
+// Token validation results.
+const (
+	TokenValid    = "valid"
+	TokenInvalid  = "invalid"
+	TokenRevoked  = "revoked"
+	TokenInactive = "inactive"
+)
+
+// Registry holds every metric of the service, it is separate from the default
+// registry so tests and libraries cannot add to it by accident.
+var Registry = prometheus.NewRegistry()
+
+var (
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "Login attempts by outcome.",
+	}, []string{"outcome"})
+
+	Signups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Registrations by outcome.",
+	}, []string{"outcome"})
+
+	TokenValidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_validations_total",
+		Help:      "Access token validations by result.",
+	}, []string{"result"})
+
+	RoleChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "role_changes_total",
+		Help:      "Role changes by the new role.",
+	}, []string{"role"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DependencyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "dependency",
+		Name:      "call_duration_seconds",
+		Help:      "Duration of calls to postgres, redis and the transaction service.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"dependency", "operation", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		LoginAttempts,
+		Signups,
+		TokenValidations,
+		RoleChanges,
+		RequestDuration,
+		DependencyDuration,
+	)
+}
+
+// Handler serves the metrics in the prometheus text format.
+func Handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	"transaction-service/metrics"
+)
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(metrics.Middleware)
+	e.GET("/user/info/:id", func(c echo.Context) error { return c.String(http.StatusOK, "ok") })
+	e.GET("/metrics", metrics.Handler())
+
+	for _, path := range []string{"/user/info/1", "/user/info/2"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	// both ids share one series
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.RequestDuration))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `auth_http_request_duration_seconds_count{method="GET",route="/user/info/:id",status="200"} 2`)
+}
+
+func TestRepositoryDecorator(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockRepo := new(mocks.UserRepository)
+		mockRepo.On("GetUserByID", mock.Anything, int64(1)).Return(&domain.User{ID: 1}, nil).Once()
+
+		user, err := metrics.UserRepository(mockRepo).GetUserByID(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), user.ID)
+
+		assert.Equal(t, 1, testutil.CollectAndCount(metrics.DependencyDuration.MustCurryWith(map[string]string{
+			"dependency": "postgres", "operation": "GetUserByID",
+		})))
+		mockRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockRedis := new(mocks.JwtTokenRepo)
+		mockRedis.On("FindTokenRepo", mock.Anything, "user:1", "token").Return(false, redis.Nil).Once()
+		mockRedis.On("DeleteTokenRepo", mock.Anything, "user:1").Return(errors.New("connection refused")).Once()
+
+		repo := metrics.JwtTokenRepo(mockRedis)
+		_, err := repo.FindTokenRepo(context.Background(), "user:1", "token")
+		assert.Equal(t, redis.Nil, err)
+		assert.Error(t, repo.DeleteTokenRepo(context.Background(), "user:1"))
+
+		rec := httptest.NewRecorder()
+		e := echo.New()
+		assert.NoError(t, metrics.Handler()(e.NewContext(httptest.NewRequest(http.MethodGet, "/metrics", nil), rec)))
+		assert.Contains(t, rec.Body.String(), `auth_dependency_call_duration_seconds_count{dependency="redis",operation="FindTokenRepo",outcome="miss"} 1`)
+		assert.Contains(t, rec.Body.String(), `auth_dependency_call_duration_seconds_count{dependency="redis",operation="DeleteTokenRepo",outcome="error"} 1`)
+		mockRedis.AssertExpectations(t)
+	})
+}
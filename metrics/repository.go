@@ -0,0 +1,272 @@
+package metrics
+
+import (
+	"context"
+	"time"
+	"transaction-service/domain"
+)
+
+// observeCall records a repository call, err points to the named result so the
+// outcome is read after the call returned.
+func observeCall(dependency, operation string, start time.Time, err *error) {
+	Observe(dependency, operation, start, *err)
+}
+
+type userRepository struct {
+	next domain.UserRepository
+}
+
+// UserRepository times the calls of next as postgres calls.
+func UserRepository(next domain.UserRepository) domain.UserRepository {
+	return &userRepository{next}
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *domain.User) (err error) {
+	defer observeCall("postgres", "CreateUser", time.Now(), &err)
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, id int64) (_ *domain.User, err error) {
+	defer observeCall("postgres", "GetUserByID", time.Now(), &err)
+	return r.next.GetUserByID(ctx, id)
+}
+
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (_ *domain.User, err error) {
+	defer observeCall("postgres", "GetUserByUsername", time.Now(), &err)
+	return r.next.GetUserByUsername(ctx, username)
+}
+
+func (r *userRepository) GetUserByIIN(ctx context.Context, iin string) (_ *domain.User, err error) {
+	defer observeCall("postgres", "GetUserByIIN", time.Now(), &err)
+	return r.next.GetUserByIIN(ctx, iin)
+}
+
+func (r *userRepository) GetAllUsers(ctx context.Context) (_ []domain.User, err error) {
+	defer observeCall("postgres", "GetAllUsers", time.Now(), &err)
+	return r.next.GetAllUsers(ctx)
+}
+
+func (r *userRepository) UpgradeUserRepo(ctx context.Context, username string) (err error) {
+	defer observeCall("postgres", "UpgradeUserRepo", time.Now(), &err)
+	return r.next.UpgradeUserRepo(ctx, username)
+}
+
+func (r *userRepository) CountUsersByRole(ctx context.Context, role string) (_ int64, err error) {
+	defer observeCall("postgres", "CountUsersByRole", time.Now(), &err)
+	return r.next.CountUsersByRole(ctx, role)
+}
+
+func (r *userRepository) UpdatePasswordRepo(ctx context.Context, id int64, password string, keep int) (err error) {
+	defer observeCall("postgres", "UpdatePasswordRepo", time.Now(), &err)
+	return r.next.UpdatePasswordRepo(ctx, id, password, keep)
+}
+
+func (r *userRepository) GetPasswordRepo(ctx context.Context, id int64) (_ string, err error) {
+	defer observeCall("postgres", "GetPasswordRepo", time.Now(), &err)
+	return r.next.GetPasswordRepo(ctx, id)
+}
+
+func (r *userRepository) GetPasswordChangedAtRepo(ctx context.Context, id int64) (_ time.Time, err error) {
+	defer observeCall("postgres", "GetPasswordChangedAtRepo", time.Now(), &err)
+	return r.next.GetPasswordChangedAtRepo(ctx, id)
+}
+
+func (r *userRepository) ListPasswordHistoryRepo(ctx context.Context, id int64, limit int) (_ []string, err error) {
+	defer observeCall("postgres", "ListPasswordHistoryRepo", time.Now(), &err)
+	return r.next.ListPasswordHistoryRepo(ctx, id, limit)
+}
+
+func (r *userRepository) SetRoleRepo(ctx context.Context, username, role string) (err error) {
+	defer observeCall("postgres", "SetRoleRepo", time.Now(), &err)
+	return r.next.SetRoleRepo(ctx, username, role)
+}
+
+func (r *userRepository) SetStatusRepo(ctx context.Context, change *domain.StatusChange) (err error) {
+	defer observeCall("postgres", "SetStatusRepo", time.Now(), &err)
+	return r.next.SetStatusRepo(ctx, change)
+}
+
+func (r *userRepository) ListStatusHistoryRepo(ctx context.Context, id int64) (_ []domain.StatusChange, err error) {
+	defer observeCall("postgres", "ListStatusHistoryRepo", time.Now(), &err)
+	return r.next.ListStatusHistoryRepo(ctx, id)
+}
+
+func (r *userRepository) DeleteUserRepo(ctx context.Context, change *domain.StatusChange) (err error) {
+	defer observeCall("postgres", "DeleteUserRepo", time.Now(), &err)
+	return r.next.DeleteUserRepo(ctx, change)
+}
+
+func (r *userRepository) ListErasableRepo(ctx context.Context, deletedBefore time.Time, limit int) (_ []int64, err error) {
+	defer observeCall("postgres", "ListErasableRepo", time.Now(), &err)
+	return r.next.ListErasableRepo(ctx, deletedBefore, limit)
+}
+
+func (r *userRepository) EraseUserRepo(ctx context.Context, id int64) (err error) {
+	defer observeCall("postgres", "EraseUserRepo", time.Now(), &err)
+	return r.next.EraseUserRepo(ctx, id)
+}
+
+type tokenRepository struct {
+	next domain.JwtTokenRepo
+}
+
+// JwtTokenRepo times the calls of next as redis calls.
+func JwtTokenRepo(next domain.JwtTokenRepo) domain.JwtTokenRepo {
+	return &tokenRepository{next}
+}
+
+func (r *tokenRepository) InsertTokenRepo(ctx context.Context, key, token string, ttl time.Duration) (err error) {
+	defer observeCall("redis", "InsertTokenRepo", time.Now(), &err)
+	return r.next.InsertTokenRepo(ctx, key, token, ttl)
+}
+
+func (r *tokenRepository) FindTokenRepo(ctx context.Context, key, token string) (_ bool, err error) {
+	defer observeCall("redis", "FindTokenRepo", time.Now(), &err)
+	return r.next.FindTokenRepo(ctx, key, token)
+}
+
+func (r *tokenRepository) DeleteTokenRepo(ctx context.Context, key string) (err error) {
+	defer observeCall("redis", "DeleteTokenRepo", time.Now(), &err)
+	return r.next.DeleteTokenRepo(ctx, key)
+}
+
+func (r *tokenRepository) ListTokensRepo(ctx context.Context, pattern string) (_ map[string]time.Duration, err error) {
+	defer observeCall("redis", "ListTokensRepo", time.Now(), &err)
+	return r.next.ListTokensRepo(ctx, pattern)
+}
+
+type impersonationRepository struct {
+	next domain.ImpersonationRepository
+}
+
+// ImpersonationRepository times the calls of next as postgres calls.
+func ImpersonationRepository(next domain.ImpersonationRepository) domain.ImpersonationRepository {
+	return &impersonationRepository{next}
+}
+
+func (r *impersonationRepository) CreateImpersonation(ctx context.Context, imp *domain.Impersonation) (err error) {
+	defer observeCall("postgres", "CreateImpersonation", time.Now(), &err)
+	return r.next.CreateImpersonation(ctx, imp)
+}
+
+func (r *impersonationRepository) EndImpersonation(ctx context.Context, actorID int64, endedAt time.Time) (err error) {
+	defer observeCall("postgres", "EndImpersonation", time.Now(), &err)
+	return r.next.EndImpersonation(ctx, actorID, endedAt)
+}
+
+type auditRepository struct {
+	next domain.AuditRepository
+}
+
+// AuditRepository times the calls of next as postgres calls.
+func AuditRepository(next domain.AuditRepository) domain.AuditRepository {
+	return &auditRepository{next}
+}
+
+func (r *auditRepository) InsertEvent(ctx context.Context, event *domain.AuditEvent) (err error) {
+	defer observeCall("postgres", "InsertEvent", time.Now(), &err)
+	return r.next.InsertEvent(ctx, event)
+}
+
+func (r *auditRepository) ListEvents(ctx context.Context, filter domain.AuditFilter) (_ []domain.AuditEvent, _ int64, err error) {
+	defer observeCall("postgres", "ListEvents", time.Now(), &err)
+	return r.next.ListEvents(ctx, filter)
+}
+
+func (r *auditRepository) ListChain(ctx context.Context, afterID int64, limit int) (_ []domain.AuditEvent, err error) {
+	defer observeCall("postgres", "ListChain", time.Now(), &err)
+	return r.next.ListChain(ctx, afterID, limit)
+}
+
+func (r *auditRepository) LastEvent(ctx context.Context) (_ *domain.AuditEvent, err error) {
+	defer observeCall("postgres", "LastEvent", time.Now(), &err)
+	return r.next.LastEvent(ctx)
+}
+
+func (r *auditRepository) InsertCheckpoint(ctx context.Context, checkpoint *domain.AuditCheckpoint) (err error) {
+	defer observeCall("postgres", "InsertCheckpoint", time.Now(), &err)
+	return r.next.InsertCheckpoint(ctx, checkpoint)
+}
+
+func (r *auditRepository) LastCheckpoint(ctx context.Context) (_ *domain.AuditCheckpoint, err error) {
+	defer observeCall("postgres", "LastCheckpoint", time.Now(), &err)
+	return r.next.LastCheckpoint(ctx)
+}
+
+func (r *auditRepository) ListCheckpoints(ctx context.Context) (_ []domain.AuditCheckpoint, err error) {
+	defer observeCall("postgres", "ListCheckpoints", time.Now(), &err)
+	return r.next.ListCheckpoints(ctx)
+}
+
+type signingKeyRepository struct {
+	next domain.SigningKeyRepository
+}
+
+// SigningKeyRepository times the calls of next as postgres calls.
+func SigningKeyRepository(next domain.SigningKeyRepository) domain.SigningKeyRepository {
+	return &signingKeyRepository{next}
+}
+
+func (r *signingKeyRepository) ListKeys(ctx context.Context, retiredAfter time.Time) (_ []domain.SigningKey, err error) {
+	defer observeCall("postgres", "ListKeys", time.Now(), &err)
+	return r.next.ListKeys(ctx, retiredAfter)
+}
+
+func (r *signingKeyRepository) CreateKey(ctx context.Context, key *domain.SigningKey) (err error) {
+	defer observeCall("postgres", "CreateKey", time.Now(), &err)
+	return r.next.CreateKey(ctx, key)
+}
+
+func (r *signingKeyRepository) RetireKeys(ctx context.Context, exceptID string, retiredAt time.Time) (err error) {
+	defer observeCall("postgres", "RetireKeys", time.Now(), &err)
+	return r.next.RetireKeys(ctx, exceptID, retiredAt)
+}
+
+func (r *signingKeyRepository) DeleteRetiredKeys(ctx context.Context, retiredBefore time.Time) (err error) {
+	defer observeCall("postgres", "DeleteRetiredKeys", time.Now(), &err)
+	return r.next.DeleteRetiredKeys(ctx, retiredBefore)
+}
+
+type companyRepository struct {
+	next domain.CompanyRepository
+}
+
+// CompanyRepository times the calls of next as postgres calls.
+func CompanyRepository(next domain.CompanyRepository) domain.CompanyRepository {
+	return &companyRepository{next}
+}
+
+func (r *companyRepository) GetCompanyByID(ctx context.Context, id int64) (_ *domain.Company, err error) {
+	defer observeCall("postgres", "GetCompanyByID", time.Now(), &err)
+	return r.next.GetCompanyByID(ctx, id)
+}
+
+func (r *companyRepository) ListUserCompanies(ctx context.Context, userID int64) (_ []domain.Membership, err error) {
+	defer observeCall("postgres", "ListUserCompanies", time.Now(), &err)
+	return r.next.ListUserCompanies(ctx, userID)
+}
+
+func (r *companyRepository) ListCompanyMembers(ctx context.Context, companyID int64) (_ []domain.CompanyMember, err error) {
+	defer observeCall("postgres", "ListCompanyMembers", time.Now(), &err)
+	return r.next.ListCompanyMembers(ctx, companyID)
+}
+
+func (r *companyRepository) GetCompanyMember(ctx context.Context, companyID, userID int64) (_ *domain.CompanyMember, err error) {
+	defer observeCall("postgres", "GetCompanyMember", time.Now(), &err)
+	return r.next.GetCompanyMember(ctx, companyID, userID)
+}
+
+func (r *companyRepository) SetCompanyMember(ctx context.Context, member *domain.CompanyMember) (err error) {
+	defer observeCall("postgres", "SetCompanyMember", time.Now(), &err)
+	return r.next.SetCompanyMember(ctx, member)
+}
+
+func (r *companyRepository) RemoveCompanyMember(ctx context.Context, companyID, userID int64) (err error) {
+	defer observeCall("postgres", "RemoveCompanyMember", time.Now(), &err)
+	return r.next.RemoveCompanyMember(ctx, companyID, userID)
+}
+
+func (r *companyRepository) CountCompanyOwners(ctx context.Context, companyID int64) (_ int64, err error) {
+	defer observeCall("postgres", "CountCompanyOwners", time.Now(), &err)
+	return r.next.CountCompanyOwners(ctx, companyID)
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrUsage is returned by RunCommand when the arguments are not understood.
+var ErrUsage = errors.New("invalid migrate command")
+
+// Usage describes the migrate command for the binary named prog.
+func Usage(prog string) string {
+	return fmt.Sprintf(`  %[1]s migrate up           apply pending migrations
+  %[1]s migrate down [n]     revert the last n migrations (default 1)
+  %[1]s migrate status       list migrations and when they were applied
+`, prog)
+}
+
+// RunCommand executes "migrate up|down [n]|status" and reports progress to out.
+func RunCommand(ctx context.Context, m *Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("%w: invalid number of steps %q", ErrUsage, args[1])
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d_%-40s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	default:
+		return ErrUsage
+	}
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, int64(i+1), m.Version, "migrations must be numbered without gaps")
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"sql/0002_second.up.sql":   {Data: []byte("up 2")},
+			"sql/0002_second.down.sql": {Data: []byte("down 2")},
+			"sql/0010_tenth.up.sql":    {Data: []byte("up 10")},
+			"sql/0010_tenth.down.sql":  {Data: []byte("down 10")},
+			"sql/0001_first.up.sql":    {Data: []byte("up 1")},
+			"sql/0001_first.down.sql":  {Data: []byte("down 1")},
+		}
+		migrations, err := load(fsys)
+		assert.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "first", Up: "up 1", Down: "down 1"},
+			{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+			{Version: 10, Name: "tenth", Up: "up 10", Down: "down 10"},
+		}, migrations)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		cases := []fstest.MapFS{
+			{"sql/0001_first.up.sql": {Data: []byte("up")}},
+			{"sql/first.up.sql": {Data: []byte("up")}, "sql/first.down.sql": {Data: []byte("down")}},
+			{"sql/0001_first.up.sql": {Data: []byte("up")}, "sql/0001_other.down.sql": {Data: []byte("down")}},
+		}
+		for _, fsys := range cases {
+			_, err := load(fsys)
+			assert.Error(t, err)
+		}
+	})
+}
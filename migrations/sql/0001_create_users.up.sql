@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY UNIQUE,
+	username VARCHAR (255) NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	iin VARCHAR (255) NOT NULL UNIQUE,
+	role VARCHAR (24) NOT NULL,
+	registerDate TEXT NOT NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS impersonations (
+	id SERIAL PRIMARY KEY,
+	actor_id INTEGER NOT NULL REFERENCES users(id),
+	target_id INTEGER NOT NULL REFERENCES users(id),
+	reason TEXT NOT NULL,
+	ip VARCHAR (64) NOT NULL,
+	user_agent TEXT NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	ended_at TIMESTAMPTZ
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	action VARCHAR (64) NOT NULL,
+	actor_id BIGINT,
+	target_id BIGINT,
+	ip VARCHAR (64) NOT NULL,
+	user_agent TEXT NOT NULL,
+	details TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
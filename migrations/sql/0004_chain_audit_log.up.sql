@@ -0,0 +1,10 @@
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS prev_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS salts JSONB NOT NULL DEFAULT '{}';
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+	id BIGSERIAL PRIMARY KEY,
+	event_id BIGINT NOT NULL,
+	hash TEXT NOT NULL,
+	signature TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+-- The legacy admin is not restored.
+SELECT 1;
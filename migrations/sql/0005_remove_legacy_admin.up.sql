@@ -0,0 +1,4 @@
+-- The admin seeded by older releases kept a plaintext password that can no
+-- longer be used to log in; the bootstrap step creates a proper one instead.
+DELETE FROM impersonations WHERE actor_id IN (SELECT id FROM users WHERE username = 'admin' AND password = 'pass');
+DELETE FROM users WHERE username = 'admin' AND password = 'pass';
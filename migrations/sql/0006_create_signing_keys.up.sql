@@ -0,0 +1,9 @@
+-- secret is the envelope sealed signing secret, secret_key_id the master key
+-- its data key is wrapped with.
+CREATE TABLE IF NOT EXISTS signing_keys (
+	id VARCHAR (64) PRIMARY KEY,
+	secret BYTEA NOT NULL,
+	secret_key_id VARCHAR (64) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	retired_at TIMESTAMPTZ
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS password_history (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	password TEXT NOT NULL,
+	changed_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS password_history_user_idx ON password_history (user_id, changed_at DESC);
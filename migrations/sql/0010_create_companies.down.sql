@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS company_members;
+DROP TABLE IF EXISTS companies;
+ALTER TABLE users DROP COLUMN IF EXISTS account_type;
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS account_type VARCHAR (24) NOT NULL DEFAULT 'individual';
+CREATE TABLE IF NOT EXISTS companies (
+	id SERIAL PRIMARY KEY,
+	bin VARCHAR (12) NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	registered_at DATE NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS company_members (
+	company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role VARCHAR (24) NOT NULL,
+	added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (company_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS company_members_user_idx ON company_members (user_id);
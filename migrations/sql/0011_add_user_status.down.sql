@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_status_history;
+ALTER TABLE users DROP COLUMN IF EXISTS status;
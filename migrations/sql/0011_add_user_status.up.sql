@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR (16) NOT NULL DEFAULT 'active';
+CREATE TABLE IF NOT EXISTS user_status_history (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	from_status VARCHAR (16) NOT NULL,
+	to_status VARCHAR (16) NOT NULL,
+	actor_id INTEGER,
+	reason TEXT NOT NULL DEFAULT '',
+	changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS user_status_history_user_idx ON user_status_history (user_id, changed_at DESC);
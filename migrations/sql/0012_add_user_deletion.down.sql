@@ -0,0 +1,4 @@
+ALTER TABLE audit_log DROP COLUMN IF EXISTS redactions;
+DROP INDEX IF EXISTS users_erasable_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS erased_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
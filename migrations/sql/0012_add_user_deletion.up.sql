@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS users_erasable_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL AND erased_at IS NULL;
+-- redactions keeps the digests of the audit fields erased with a user, see
+-- domain.AuditEvent.Redact.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS redactions JSONB NOT NULL DEFAULT '{}';
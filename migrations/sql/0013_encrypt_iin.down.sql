@@ -0,0 +1,14 @@
+-- The keys are not available to SQL, sealed IINs would be lost.
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM users WHERE iin IS NULL AND iin_encrypted IS NOT NULL) THEN
+		RAISE EXCEPTION 'users have encrypted IINs only, restore them from a backup before rolling back';
+	END IF;
+END $$;
+DROP INDEX IF EXISTS users_iin_key_idx;
+DROP INDEX IF EXISTS users_iin_index_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS iin_index;
+ALTER TABLE users DROP COLUMN IF EXISTS iin_key_id;
+ALTER TABLE users DROP COLUMN IF EXISTS iin_encrypted;
+UPDATE users SET iin='erased-'||id WHERE iin IS NULL AND erased_at IS NOT NULL;
+ALTER TABLE users ALTER COLUMN iin SET NOT NULL;
@@ -0,0 +1,12 @@
+-- iin_encrypted is the envelope sealed IIN, iin_key_id the master key its data
+-- key is wrapped with and iin_index the HMAC blind index used for lookups and
+-- uniqueness. Existing IINs stay in the iin column until authctl reencrypt-iin
+-- seals them, the repository reads both forms meanwhile.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS iin_encrypted BYTEA;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS iin_key_id VARCHAR (64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS iin_index BYTEA;
+ALTER TABLE users ALTER COLUMN iin DROP NOT NULL;
+-- erased accounts keep no IIN at all
+UPDATE users SET iin=NULL WHERE erased_at IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_iin_index_idx ON users (iin_index);
+CREATE INDEX IF NOT EXISTS users_iin_key_idx ON users (iin_key_id);
@@ -0,0 +1,97 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+)
+
+// breachedFalsePositive is the share of passwords wrongly reported as
+// breached, they only have to pick another password.
+const breachedFalsePositive = 0.001
+
+// Bloom is a bloom filter of passwords. It answers whether a password may be
+// in the set in constant memory, whatever the size of the list it was built from.
+type Bloom struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// NewBloom returns an empty filter sized for n entries with the given false
+// positive rate.
+func NewBloom(n int, falsePositive float64) *Bloom {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositive) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &Bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// LoadBloom builds the filter of a password list, one password per line.
+// Empty lines and lines starting with # are skipped, case is ignored.
+func LoadBloom(path string) (*Bloom, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open password list: %w", err)
+	}
+	defer f.Close()
+
+	var passwords []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := normalize(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read password list %s: %w", path, err)
+	}
+
+	b := NewBloom(len(passwords), breachedFalsePositive)
+	for _, pass := range passwords {
+		b.Add(pass)
+	}
+	return b, nil
+}
+
+// Add puts s in the filter.
+func (b *Bloom) Add(s string) {
+	h1, h2 := hashes(s)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Test reports whether s may have been added, it is never wrong when it
+// returns false.
+func (b *Bloom) Test(s string) bool {
+	h1, h2 := hashes(s)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashes derives the k bit positions from two hashes (Kirsch and Mitzenmacher).
+func hashes(s string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	h1 := h.Sum64()
+	h.Write([]byte{0})
+	// odd so the positions do not repeat early
+	return h1, h.Sum64() | 1
+}
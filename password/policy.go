@@ -0,0 +1,259 @@
+// Package password checks candidate passwords against the configured policy
+// and estimates their strength for the live feedback of the signup page.
+package password
+
+import (
+	"math"
+	"strings"
+	"time"
+	"transaction-service/validation"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scores returned by Score, from a trivially guessable password to a strong one.
+const (
+	ScoreVeryWeak = iota
+	ScoreWeak
+	ScoreFair
+	ScoreGood
+	ScoreStrong
+)
+
+// minIINRun is the shortest part of the IIN rejected in a password, the first
+// six digits are the birth date.
+const minIINRun = 6
+
+// minUsernameLength is the shortest username rejected in a password, shorter
+// ones match too many passwords by chance.
+const minUsernameLength = 3
+
+// Policy are the rules a password must follow.
+type Policy struct {
+	// MinLength and MaxLength count characters, not bytes. A MaxLength of 0 is unlimited.
+	MinLength int
+	MaxLength int
+
+	RequireLower   bool
+	RequireUpper   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	// AllowUnicode accepts letters, digits and symbols outside ASCII, they
+	// count towards the character classes. Control characters are always rejected.
+	AllowUnicode bool
+	// RejectPersonal rejects passwords containing the username or part of the IIN.
+	RejectPersonal bool
+	// MinScore is the lowest accepted Score, 0 accepts any.
+	MinScore int
+	// Breached lists common and leaked passwords, nil disables the check.
+	Breached *Bloom
+	// History is how many previous passwords of a user cannot be reused, the
+	// current one never can.
+	History int
+	// MaxAge is how long the password of a role is valid, roles missing from
+	// it are never asked to rotate their password.
+	MaxAge map[string]time.Duration
+}
+
+// DefaultPolicy returns the rules used before the policy was configurable,
+// without their ASCII only restriction.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MinLength:      6,
+		MaxLength:      128,
+		RequireLower:   true,
+		RequireUpper:   true,
+		RequireDigit:   true,
+		AllowUnicode:   true,
+		RejectPersonal: true,
+		History:        5,
+	}
+}
+
+// Report is the verdict on a password, the signup page shows it while typing.
+type Report struct {
+	Score  int               `json:"score"`
+	Valid  bool              `json:"valid"`
+	Errors validation.Errors `json:"errors,omitempty"`
+}
+
+// Check evaluates pass, personal holds the username and the IIN of its owner.
+func (p *Policy) Check(pass string, personal ...string) Report {
+	var errs validation.Errors
+	add := func(code string, args ...interface{}) {
+		errs.Add("password", validation.NewFieldError("password", code, args...))
+	}
+
+	length := utf8.RuneCountInString(pass)
+	if length < p.MinLength {
+		add(validation.CodePasswordTooShort, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(validation.CodePasswordTooLong, p.MaxLength)
+	}
+
+	classes := classify(pass)
+	if classes.control > 0 || (!p.AllowUnicode && classes.unicode > 0) {
+		add(validation.CodePasswordChars)
+	}
+	if p.RequireLower && classes.lower == 0 {
+		add(validation.CodePasswordLower)
+	}
+	if p.RequireUpper && classes.upper == 0 {
+		add(validation.CodePasswordUpper)
+	}
+	if p.RequireDigit && classes.digit == 0 {
+		add(validation.CodePasswordDigit)
+	}
+	if p.RequireSpecial && classes.special == 0 {
+		add(validation.CodePasswordSpecial)
+	}
+	if p.RejectPersonal && containsPersonal(pass, personal) {
+		add(validation.CodePasswordPersonal)
+	}
+
+	breached := p.breached(pass)
+	if breached {
+		add(validation.CodePasswordBreached)
+	}
+	score := ScoreVeryWeak
+	if !breached {
+		score = Score(pass)
+	}
+	// the other rules already say what to change
+	if len(errs) == 0 && score < p.MinScore {
+		add(validation.CodePasswordWeak)
+	}
+	return Report{Score: score, Valid: len(errs) == 0, Errors: errs}
+}
+
+// Validate returns the failed rules of pass as validation.Errors of the
+// password field, nil when pass is acceptable.
+func (p *Policy) Validate(pass string, personal ...string) error {
+	return p.Check(pass, personal...).Errors.Err()
+}
+
+// Expired reports whether a password of role set at changedAt must be changed at now.
+func (p *Policy) Expired(role string, changedAt, now time.Time) bool {
+	maxAge, ok := p.MaxAge[role]
+	return ok && maxAge > 0 && now.Sub(changedAt) > maxAge
+}
+
+func (p *Policy) breached(pass string) bool {
+	return p.Breached != nil && p.Breached.Test(normalize(pass))
+}
+
+// Score estimates how hard pass is to guess from its length and alphabet,
+// repeated characters and sequences such as abc or 321 count for little.
+func Score(pass string) int {
+	classes := classify(pass)
+	pool := 0
+	for _, class := range []struct{ count, size int }{
+		{classes.lower, 26},
+		{classes.upper, 26},
+		{classes.digit, 10},
+		{classes.special, 33},
+		{classes.unicode, 100},
+	} {
+		if class.count > 0 {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return ScoreVeryWeak
+	}
+
+	effective := 0.0
+	prev := rune(-1)
+	for _, r := range pass {
+		if d := r - prev; d >= -1 && d <= 1 {
+			effective += 0.25
+		} else {
+			effective++
+		}
+		prev = r
+	}
+
+	bits := effective * math.Log2(float64(pool))
+	switch {
+	case bits < 28:
+		return ScoreVeryWeak
+	case bits < 36:
+		return ScoreWeak
+	case bits < 60:
+		return ScoreFair
+	case bits < 80:
+		return ScoreGood
+	}
+	return ScoreStrong
+}
+
+type classCount struct {
+	lower, upper, digit, special, unicode, control int
+}
+
+func classify(pass string) classCount {
+	var c classCount
+	for _, r := range pass {
+		switch {
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			c.control++
+		case r > unicode.MaxASCII:
+			c.unicode++
+			// letters of other scripts count towards their case
+			if unicode.IsLower(r) {
+				c.lower++
+			} else if unicode.IsUpper(r) {
+				c.upper++
+			} else if unicode.IsDigit(r) {
+				c.digit++
+			} else {
+				c.special++
+			}
+		case r >= 'a' && r <= 'z':
+			c.lower++
+		case r >= 'A' && r <= 'Z':
+			c.upper++
+		case r >= '0' && r <= '9':
+			c.digit++
+		default:
+			c.special++
+		}
+	}
+	return c
+}
+
+// containsPersonal reports whether pass contains a username or a run of an
+// IIN, personal values that are all digits are treated as IINs.
+func containsPersonal(pass string, personal []string) bool {
+	pass = normalize(pass)
+	for _, value := range personal {
+		value = normalize(value)
+		if isDigits(value) && len(value) >= minIINRun {
+			for i := 0; i+minIINRun <= len(value); i++ {
+				if strings.Contains(pass, value[i:i+minIINRun]) {
+					return true
+				}
+			}
+			continue
+		}
+		if utf8.RuneCountInString(value) >= minUsernameLength && strings.Contains(pass, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// normalize makes the comparisons case insensitive.
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
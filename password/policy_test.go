@@ -0,0 +1,116 @@
+package password_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"transaction-service/password"
+	"transaction-service/validation"
+)
+
+func codes(r password.Report) []string {
+	codes := []string{}
+	for _, f := range r.Errors {
+		codes = append(codes, f.Code)
+	}
+	return codes
+}
+
+func TestCheck(t *testing.T) {
+	policy := password.DefaultPolicy()
+	cases := map[string][]string{
+		"Asd123@":                {},
+		"Qwe123!@!":              {},
+		"correct Horse 9 staple": {},
+		"Пароль2022":             {},
+		"asdasad":                {validation.CodePasswordUpper, validation.CodePasswordDigit},
+		"123456":                 {validation.CodePasswordLower, validation.CodePasswordUpper},
+		"1234":                   {validation.CodePasswordTooShort, validation.CodePasswordLower, validation.CodePasswordUpper},
+		"Qwe123\x00":             {validation.CodePasswordChars},
+		"Jack2022!":              {validation.CodePasswordPersonal},
+		"Born940217!":            {validation.CodePasswordPersonal},
+	}
+	for pass, want := range cases {
+		r := policy.Check(pass, "jack", "940217450216")
+		assert.Equal(t, want, codes(r), pass)
+		assert.Equal(t, len(want) == 0, r.Valid, pass)
+	}
+
+	long := &password.Policy{MinLength: 2, MaxLength: 4}
+	assert.Equal(t, []string{validation.CodePasswordTooLong}, codes(long.Check("abcde")))
+
+	ascii := &password.Policy{}
+	assert.Equal(t, []string{validation.CodePasswordChars}, codes(ascii.Check("Пароль")))
+
+	special := &password.Policy{RequireSpecial: true}
+	assert.Equal(t, []string{validation.CodePasswordSpecial}, codes(special.Check("Qwe123")))
+}
+
+func TestCheckScore(t *testing.T) {
+	policy := &password.Policy{AllowUnicode: true, MinScore: password.ScoreFair}
+
+	r := policy.Check("aaaaaaaaaa")
+	assert.Equal(t, []string{validation.CodePasswordWeak}, codes(r))
+	assert.Equal(t, password.ScoreVeryWeak, r.Score)
+
+	r = policy.Check("tr0ub4dor&3x")
+	assert.True(t, r.Valid)
+	assert.GreaterOrEqual(t, r.Score, password.ScoreFair)
+
+	assert.Equal(t, password.ScoreVeryWeak, password.Score(""))
+	assert.Equal(t, password.ScoreVeryWeak, password.Score("abcdefgh"))
+	assert.Equal(t, password.ScoreStrong, password.Score("J7#kq!Vz2@Lm9$Rw"))
+}
+
+func TestExpired(t *testing.T) {
+	policy := &password.Policy{MaxAge: map[string]time.Duration{"admin": 90 * 24 * time.Hour}}
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.False(t, policy.Expired("admin", now.AddDate(0, 0, -89), now))
+	assert.True(t, policy.Expired("admin", now.AddDate(0, 0, -91), now))
+	assert.False(t, policy.Expired("user", now.AddDate(-5, 0, 0), now))
+	assert.False(t, password.DefaultPolicy().Expired("admin", now.AddDate(-5, 0, 0), now))
+}
+
+func TestBreached(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "common.txt")
+	require.NoError(t, os.WriteFile(list, []byte("# most common\nqwerty\nP@ssw0rd\n\nletmein\n"), 0o600))
+
+	breached, err := password.LoadBloom(list)
+	require.NoError(t, err)
+	assert.True(t, breached.Test("qwerty"))
+	assert.True(t, breached.Test("p@ssw0rd"))
+	assert.False(t, breached.Test("# most common"))
+
+	policy := password.DefaultPolicy()
+	policy.Breached = breached
+	r := policy.Check("P@ssw0rd")
+	assert.Equal(t, []string{validation.CodePasswordBreached}, codes(r))
+	assert.Equal(t, password.ScoreVeryWeak, r.Score)
+	assert.True(t, policy.Check("Qwe123!@!").Valid)
+
+	_, err = password.LoadBloom(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestBloom(t *testing.T) {
+	b := password.NewBloom(1000, 0.001)
+	for i := 0; i < 1000; i++ {
+		b.Add(string(rune('a'+i%26)) + string(rune(i)))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, b.Test(string(rune('a'+i%26))+string(rune(i))))
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if b.Test("absent" + string(rune(i))) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 50)
+}
@@ -0,0 +1,84 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"transaction-service/config"
+	"transaction-service/tracing"
+)
+
+func recorder(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return rec
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		shutdown, err := tracing.Setup(context.Background(), config.Tracing{Exporter: tracing.ExporterNone})
+		assert.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+	t.Run("error-unknown-exporter", func(t *testing.T) {
+		_, err := tracing.Setup(context.Background(), config.Tracing{Exporter: "jaeger"})
+		assert.Error(t, err)
+	})
+}
+
+func TestRepositorySpans(t *testing.T) {
+	rec := recorder(t)
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "GET /user/info/all")
+
+	_, span := tracing.Postgres(ctx, "userRepository.GetAllRepo")
+	span.End()
+	_, span = tracing.Redis(ctx, "redisRepo.FindTokenRepo")
+	err := tracing.Fail(span, errors.New("connection refused"))
+	span.End()
+	parent.End()
+
+	assert.EqualError(t, err, "connection refused")
+	spans := rec.Ended()
+	assert.Len(t, spans, 3)
+	for _, s := range spans[:2] {
+		assert.Equal(t, parent.SpanContext().TraceID(), s.SpanContext().TraceID())
+		assert.Equal(t, parent.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestPropagation(t *testing.T) {
+	recorder(t)
+	_, err := tracing.Setup(context.Background(), config.Tracing{Exporter: tracing.ExporterNone})
+	assert.NoError(t, err)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "GET /user/info/:id")
+	defer span.End()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	res, err := client.Do(req)
+	assert.NoError(t, err)
+	res.Body.Close()
+
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"transaction-service/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListCompanies shows the companies the signed in user acts on behalf of.
+func (u *UserHandler) ListCompanies(e echo.Context) error {
+
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+	memberships, err := u.CompanyUsecase.ListUserCompanies(e.Request().Context(), meta.ID)
+	if err != nil {
+		return err
+	}
+	return e.Render(http.StatusOK, "companies.html", memberships)
+}
+
+func (u *UserHandler) CompanyPage(e echo.Context) error {
+
+	companyID, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return domain.Validation(domain.CodeInvalidInput, "Invalid ID", err)
+	}
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+	profile, err := u.CompanyUsecase.GetCompany(e.Request().Context(), meta, int64(companyID))
+	if err != nil {
+		return err
+	}
+	return e.Render(http.StatusOK, "company.html", profile)
+}
+
+// SetCompanyMember adds a user by username to the company or changes the role of a member.
+func (u *UserHandler) SetCompanyMember(e echo.Context) error {
+
+	companyID, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return domain.Validation(domain.CodeInvalidInput, "Invalid ID", err)
+	}
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+	member, err := u.CompanyUsecase.SetCompanyMember(e.Request().Context(), meta, int64(companyID), e.FormValue("username"), e.FormValue("role"))
+	if err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditCompanyMemberSet, meta.ID, member.UserID, fmt.Sprintf("company %d role %s", companyID, member.Role))
+	return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/user/companies/%d", companyID))
+}
+
+func (u *UserHandler) RemoveCompanyMember(e echo.Context) error {
+
+	companyID, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return domain.Validation(domain.CodeInvalidInput, "Invalid ID", err)
+	}
+	userID, err := strconv.Atoi(e.Param("user"))
+	if err != nil {
+		return domain.Validation(domain.CodeInvalidInput, "Invalid ID", err)
+	}
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+	if err := u.CompanyUsecase.RemoveCompanyMember(e.Request().Context(), meta, int64(companyID), int64(userID)); err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditCompanyMemberRemove, meta.ID, int64(userID), fmt.Sprintf("company %d", companyID))
+	if meta.ID == int64(userID) {
+		return e.Redirect(http.StatusSeeOther, "/user/companies")
+	}
+	return e.Redirect(http.StatusSeeOther, fmt.Sprintf("/user/companies/%d", companyID))
+}
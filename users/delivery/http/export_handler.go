@@ -0,0 +1,56 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"transaction-service/domain"
+	"transaction-service/export"
+	"transaction-service/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ExportData downloads the personal data of the signed in user, as JSON or
+// with ?format=zip as a ZIP archive of CSV files.
+func (u *UserHandler) ExportData(e echo.Context) error {
+
+	format := e.QueryParam("format")
+	if format == "" {
+		format = domain.ExportJSON
+	}
+	if format != domain.ExportJSON && format != domain.ExportZIP {
+		return domain.Validation(domain.CodeInvalidInput, "format must be json or zip", fmt.Errorf("export format %q", format))
+	}
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+
+	data, err := u.ExportUsecase.ExportUserData(e.Request().Context(), meta.ID)
+	if err != nil {
+		return err
+	}
+	// the token carries no IIN, the profile does. A transaction service outage
+	// leaves the accounts out instead of failing the export
+	accounts, err := GetAccountInfo(e, data.Profile.IIN)
+	switch {
+	case err == nil:
+		data.Accounts = accounts
+	case domain.KindOf(err) != domain.KindNotFound:
+		logging.From(e).Err(err).Msg("account info unavailable for the export")
+		data.Unavailable = append(data.Unavailable, "accounts")
+	}
+	u.audit(e, domain.AuditDataExport, meta.ID, meta.ID, format)
+
+	res := e.Response()
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", export.FileName(data, format)))
+	res.Header().Set("Cache-Control", "no-store")
+	if format == domain.ExportZIP {
+		res.Header().Set(echo.HeaderContentType, "application/zip")
+		res.WriteHeader(http.StatusOK)
+		return export.WriteZIP(res, data)
+	}
+	res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	res.WriteHeader(http.StatusOK)
+	return export.WriteJSON(res, data)
+}
@@ -0,0 +1,52 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	userHTTP "transaction-service/users/delivery/http"
+)
+
+func TestExportData(t *testing.T) {
+	accounts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/account/info/940217450216/auth" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode([]domain.Accounts{{Number: "KZ01", Balance: 500}})
+	}))
+	defer accounts.Close()
+	previous := userHTTP.AccountServiceURL
+	userHTTP.AccountServiceURL = accounts.URL
+	defer func() { userHTTP.AccountServiceURL = previous }()
+
+	mockExport := new(mocks.ExportUsecase)
+	mockExport.On("ExportUserData", mock.Anything, int64(7)).
+		Return(&domain.DataExport{Profile: domain.ExportProfile{ID: 7, IIN: "940217450216"}, Accounts: []domain.Accounts{}}, nil).Once()
+
+	req := httptest.NewRequest(echo.GET, "/user/export", nil)
+	req.AddCookie(&http.Cookie{Name: "access-token", Value: "token"})
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	// the token middleware only sets the ID and the role
+	c.Set("user", domain.User{ID: 7, Role: "user"})
+
+	handler := userHTTP.UserHandler{ExportUsecase: mockExport}
+	require.NoError(t, handler.ExportData(c))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var data domain.DataExport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &data))
+	assert.Equal(t, []domain.Accounts{{Number: "KZ01", Balance: 500}}, data.Accounts)
+	assert.Empty(t, data.Unavailable)
+	mockExport.AssertExpectations(t)
+}
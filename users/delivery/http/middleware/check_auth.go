@@ -1,20 +1,22 @@
 package middleware
 
 import (
+	"fmt"
 	"transaction-service/domain"
-
-	"github.com/rs/zerolog/log"
+	"transaction-service/logging"
+	"transaction-service/metrics"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
 type Authorization struct {
-	JwtUsecase domain.JwtTokenUsecase
+	JwtUsecase  domain.JwtTokenUsecase
+	UserUsecase domain.UserUsecase
 }
 
-func InitAuthorization(jwtuc domain.JwtTokenUsecase) *Authorization {
-	return &Authorization{JwtUsecase: jwtuc}
+func InitAuthorization(jwtuc domain.JwtTokenUsecase, us domain.UserUsecase) *Authorization {
+	return &Authorization{JwtUsecase: jwtuc, UserUsecase: us}
 }
 
 func (a *Authorization) GetConfig() middleware.JWTConfig {
@@ -29,33 +31,93 @@ func (a *Authorization) CheckToken(auth string, c echo.Context) (interface{}, er
 
 	id, err := a.JwtUsecase.ParseTokenAndGetID(auth)
 	if err != nil {
-		logErr := err.(*domain.LogError)
-		log.Err(logErr).Msg(logErr.Message)
+		logging.From(c).Err(err).Msg("invalid token")
+		metrics.TokenValidations.WithLabelValues(metrics.TokenInvalid).Inc()
+		return nil, err
+	}
+	actor, err := a.JwtUsecase.ParseTokenAndGetActor(auth)
+	if err != nil {
+		logging.From(c).Err(err).Msg("invalid token")
+		metrics.TokenValidations.WithLabelValues(metrics.TokenInvalid).Inc()
+		return nil, err
+	}
+	// impersonation sessions are kept under the actor, the target's own session stays untouched
+	var ok bool
+	if actor != nil {
+		ok, err = a.JwtUsecase.FindImpersonationToken(c.Request().Context(), actor.ID, auth)
+	} else {
+		ok, err = a.JwtUsecase.FindToken(c.Request().Context(), id, auth)
+	}
+	if err != nil {
+		logging.From(c).Err(err).Msg("invalid token")
+		metrics.TokenValidations.WithLabelValues(metrics.TokenRevoked).Inc()
+		return nil, err
+	}
+	if !ok {
+		err := domain.Unauthorized(domain.CodeSessionNotFound, "session not found", fmt.Errorf("token of user %d is not an active session", id))
+		logging.From(c).Err(err).Msg("invalid token")
+		metrics.TokenValidations.WithLabelValues(metrics.TokenRevoked).Inc()
+		return nil, err
+	}
+	// a session outlives neither the suspension nor the closing of the account,
+	// impersonation stops when the staff account does
+	owner := id
+	if actor != nil {
+		owner = actor.ID
+	}
+	user, err := a.UserUsecase.GetUserByIDUsecase(c.Request().Context(), owner)
+	if err == nil {
+		err = domain.StatusError(user)
+	}
+	if err != nil {
+		logging.From(c).Err(err).Msg("invalid token")
+		metrics.TokenValidations.WithLabelValues(metrics.TokenInactive).Inc()
 		return nil, err
 	}
-	if ok, err := a.JwtUsecase.FindToken(id, auth); err != nil || !ok {
-		logErr := err.(*domain.LogError)
-		log.Err(logErr).Msg(logErr.Message)
+	// the token carries the role the actor had when the impersonation started
+	if actor != nil && !domain.HasPermission(user.Role, domain.PermImpersonate) {
+		err := domain.Unauthorized(domain.CodeImpersonation, "impersonation is no longer allowed",
+			fmt.Errorf("actor %d with role %s impersonating user %d", actor.ID, user.Role, id))
+		logging.From(c).Err(err).Msg("invalid token")
+		metrics.TokenValidations.WithLabelValues(metrics.TokenRevoked).Inc()
 		return nil, err
 	}
 	role, err := a.JwtUsecase.ParseTokenAndGetRole(auth)
 	if err != nil {
-		logErr := err.(*domain.LogError)
-		log.Err(logErr).Msg(logErr.Message)
+		logging.From(c).Err(err).Msg("invalid token")
+		metrics.TokenValidations.WithLabelValues(metrics.TokenInvalid).Inc()
 		return nil, err
 	}
+	metrics.TokenValidations.WithLabelValues(metrics.TokenValid).Inc()
+	var actorID int64
+	if actor != nil {
+		actorID = actor.ID
+	}
+	logging.SetUser(c, id, actorID)
 	info := domain.User{
-		ID:   id,
-		Role: role,
+		ID:    id,
+		Role:  role,
+		Actor: actor,
 	}
 	return info, nil
 }
 
+// DenyImpersonation blocks sensitive actions for requests made with an impersonation token.
+func (a *Authorization) DenyImpersonation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		meta, ok := c.Get("user").(domain.User)
+		if ok && meta.Actor != nil {
+			return domain.Forbidden(domain.CodeImpersonation, "This action is not available while impersonating a user",
+				fmt.Errorf("actor %d impersonating user %d", meta.Actor.ID, meta.ID))
+		}
+		return next(c)
+	}
+}
+
 func (a *Authorization) SetHeaders(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		c.Response().Header().Set("Access-Control-Allow-Origin", "*")
 		c.Response().Header().Set("Content-Type", "text/html")
-		next(c)
-		return nil
+		return next(c)
 	}
 }
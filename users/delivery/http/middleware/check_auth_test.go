@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	"transaction-service/users/delivery/http/middleware"
+)
+
+func TestCheckTokenImpersonation(t *testing.T) {
+	check := func(actorRole string) (interface{}, error) {
+		mockJWT := new(mocks.JwtTokenUsecase)
+		mockJWT.On("ParseTokenAndGetID", "token").Return(int64(7), nil)
+		mockJWT.On("ParseTokenAndGetActor", "token").Return(&domain.Actor{ID: 2, Role: "support"}, nil)
+		mockJWT.On("FindImpersonationToken", mock.Anything, int64(2), "token").Return(true, nil)
+		mockJWT.On("ParseTokenAndGetRole", "token").Return("user", nil).Maybe()
+		mockUCase := new(mocks.UserUsecase)
+		mockUCase.On("GetUserByIDUsecase", mock.Anything, int64(2)).
+			Return(&domain.User{ID: 2, Role: actorRole, Status: domain.StatusActive}, nil)
+
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/user/home", nil), httptest.NewRecorder())
+		return middleware.InitAuthorization(mockJWT, mockUCase).CheckToken("token", c)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		info, err := check("support")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), info.(domain.User).ID)
+	})
+	t.Run("actor-demoted", func(t *testing.T) {
+		_, err := check("user")
+
+		assert.Equal(t, domain.KindUnauthorized, domain.KindOf(err))
+		assert.Equal(t, domain.CodeImpersonation, domain.CodeOf(err))
+	})
+}
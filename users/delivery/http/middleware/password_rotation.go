@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"transaction-service/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePasswordRotation sends users whose password expired to changePath
+// and blocks every other route it guards until the password is changed.
+// Impersonation sessions are let through, staff never rotates a user's password.
+func RequirePasswordRotation(users domain.UserUsecase, changePath string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			meta, ok := c.Get("user").(domain.User)
+			if !ok || meta.Actor != nil || c.Path() == changePath {
+				return next(c)
+			}
+			expired, err := users.PasswordExpiredUsecase(c.Request().Context(), meta.ID, meta.Role)
+			if err != nil {
+				return err
+			}
+			if !expired {
+				return next(c)
+			}
+			if c.Request().Method == http.MethodGet {
+				return c.Redirect(http.StatusSeeOther, changePath+"?expired=1")
+			}
+			return domain.Forbidden(domain.CodePasswordExpired, "Your password has expired, change it to continue",
+				fmt.Errorf("user %d with expired password requested %s", meta.ID, c.Path()))
+		}
+	}
+}
@@ -8,39 +8,68 @@ import (
 
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 	"transaction-service/domain"
+	"transaction-service/httperror"
+	"transaction-service/logging"
+	"transaction-service/metrics"
 	config "transaction-service/users/delivery/http/middleware"
 
 	utils "transaction-service/utils"
-
-	"github.com/rs/zerolog/log"
+	"transaction-service/validation"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type UserHandler struct {
-	UserUsecase domain.UserUsecase
-	JwtUsecase  domain.JwtTokenUsecase
+	UserUsecase          domain.UserUsecase
+	JwtUsecase           domain.JwtTokenUsecase
+	ImpersonationUsecase domain.ImpersonationUsecase
+	AuditUsecase         domain.AuditUsecase
+	CompanyUsecase       domain.CompanyUsecase
+	ExportUsecase        domain.ExportUsecase
 }
 
 type Template struct {
+	// base is never executed so it can be cloned with request specific functions
+	base      *template.Template
 	templates *template.Template
 }
 
+func NewTemplate(pattern string) *Template {
+	base := template.Must(template.New("").Funcs(templateFuncs(nil)).ParseGlob(pattern))
+	return &Template{
+		base:      base,
+		templates: template.Must(base.Clone()),
+	}
+}
+
 func (t *Template) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
-	return t.templates.ExecuteTemplate(w, name, data)
+	meta, ok := c.Get("user").(domain.User)
+	if !ok || meta.Actor == nil {
+		return t.templates.ExecuteTemplate(w, name, data)
+	}
+	tmpl, err := t.base.Clone()
+	if err != nil {
+		return err
+	}
+	return tmpl.Funcs(templateFuncs(meta.Actor)).ExecuteTemplate(w, name, data)
 }
 
-func NewUserHandler(e *echo.Echo, us domain.UserUsecase, jwt domain.JwtTokenUsecase) {
-	t := &Template{
-		templates: template.Must(template.ParseGlob("templates/*.html")),
+func templateFuncs(actor *domain.Actor) template.FuncMap {
+	return template.FuncMap{
+		"impersonator": func() *domain.Actor { return actor },
 	}
-	e.Renderer = t
+}
 
-	handler := &UserHandler{UserUsecase: us, JwtUsecase: jwt}
-	midd := config.InitAuthorization(jwt)
+func NewUserHandler(e *echo.Echo, us domain.UserUsecase, jwt domain.JwtTokenUsecase, imp domain.ImpersonationUsecase, audit domain.AuditUsecase, company domain.CompanyUsecase, export domain.ExportUsecase) {
+	e.Renderer = NewTemplate("templates/*.html")
+
+	handler := &UserHandler{UserUsecase: us, JwtUsecase: jwt, ImpersonationUsecase: imp, AuditUsecase: audit, CompanyUsecase: company, ExportUsecase: export}
+	midd := config.InitAuthorization(jwt, us)
 
 	e.Use(midd.SetHeaders)
 
@@ -49,32 +78,45 @@ func NewUserHandler(e *echo.Echo, us domain.UserUsecase, jwt domain.JwtTokenUsec
 
 	e.GET("/signup", handler.RegistrationPage)
 	e.POST("/signup", handler.Registration)
-	e.GET("/", handler.Home, middleware.JWTWithConfig(midd.GetConfig()))
+	e.POST("/signup/password", handler.PasswordStrength, httperror.JSON)
+	e.POST("/oauth/token", handler.TokenExchange)
+	rotation := config.RequirePasswordRotation(us, "/user/password")
+	e.GET("/", handler.Home, middleware.JWTWithConfig(midd.GetConfig()), rotation)
 
 	infoGroup := e.Group("/user")
-	infoGroup.Use(middleware.JWTWithConfig(midd.GetConfig()))
+	infoGroup.Use(middleware.JWTWithConfig(midd.GetConfig()), rotation)
 
 	infoGroup.GET("/info/all", handler.GetAllUserInfo)
 	infoGroup.GET("/info/:id", handler.GetUserInfo)
-	infoGroup.GET("/upgrade/:username", handler.UpgradeRole)
+	infoGroup.GET("/upgrade/:username", handler.UpgradeRole, midd.DenyImpersonation)
+	infoGroup.POST("/status/:username", handler.ChangeStatus, midd.DenyImpersonation)
+	infoGroup.POST("/delete/:username", handler.DeleteUser, midd.DenyImpersonation)
+	infoGroup.GET("/close", handler.CloseAccountPage, midd.DenyImpersonation)
+	infoGroup.POST("/close", handler.CloseAccount, midd.DenyImpersonation)
 	infoGroup.GET("/home", handler.Home)
+	infoGroup.GET("/export", handler.ExportData, midd.DenyImpersonation)
+	infoGroup.GET("/password", handler.ChangePasswordPage, midd.DenyImpersonation)
+	infoGroup.POST("/password", handler.ChangePassword, midd.DenyImpersonation)
+	infoGroup.POST("/impersonate/stop", handler.StopImpersonation)
+	infoGroup.POST("/impersonate/:id", handler.StartImpersonation, midd.DenyImpersonation)
+	infoGroup.GET("/companies", handler.ListCompanies)
+	infoGroup.GET("/companies/:id", handler.CompanyPage)
+	infoGroup.POST("/companies/:id/members", handler.SetCompanyMember, midd.DenyImpersonation)
+	infoGroup.POST("/companies/:id/members/:user/remove", handler.RemoveCompanyMember, midd.DenyImpersonation)
 
 }
 
 func (u *UserHandler) Home(e echo.Context) error {
 	meta, ok := e.Get("user").(domain.User)
 	if !ok {
-		log.Err(domain.ErrorMetaNotFound).Msg("unauthorized")
-		return e.Render(http.StatusUnauthorized, "error.html", "access denied")
+		return domain.ErrUnauthenticated
 	}
 
 	ctx := e.Request().Context()
 
 	user, err := u.UserUsecase.GetUserByIDUsecase(ctx, meta.ID)
 	if err != nil {
-		logerr := err.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		return e.String(logerr.Code, "Access denied")
+		return err
 	}
 	// return e.JSON(http.StatusOK, user)
 	return e.Render(http.StatusOK, "home.html", user)
@@ -84,36 +126,45 @@ func (u *UserHandler) Signin(e echo.Context) error {
 
 	creds := u.ExtractCreds(e)
 	if creds.Username == "" || creds.Password == "" {
-		log.Log().Msg("username or password must be filled")
-		return e.Render(http.StatusBadRequest, "error.html", "username or password must be filled")
+		return domain.Validation(domain.CodeInvalidInput, "username or password must be filled", nil)
 	}
 	ctx := e.Request().Context()
 	user, err := u.UserUsecase.GetUserByNameUsecase(ctx, creds.Username)
+	if domain.KindOf(err) == domain.KindNotFound {
+		// the typed value is left out, it is often a mistyped password
+		u.audit(e, domain.AuditLoginFailure, 0, 0, "unknown username")
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginUnknownUser).Inc()
+		// the same answer as for a wrong password, so usernames cannot be probed
+		return domain.Unauthorized(domain.CodeInvalidCredentials, "incorrect username or password", err)
+	}
 	if err != nil {
-		logerr := err.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		return e.Render(logerr.Code, "error.html", "incorrect username")
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginError).Inc()
+		return err
 	}
 	if !utils.ComparePasswordHash(user.Password, creds.Password) {
-		log.Log().Msg("incorrect password")
-		return e.Render(http.StatusForbidden, "error.html", "incorrect password")
+		u.audit(e, domain.AuditLoginFailure, user.ID, user.ID, "incorrect password")
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginBadPassword).Inc()
+		return domain.Unauthorized(domain.CodeInvalidCredentials, "incorrect username or password", nil)
+	}
+	if err := domain.StatusError(user); err != nil {
+		u.audit(e, domain.AuditLoginFailure, user.ID, user.ID, "account "+user.Status)
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginInactive).Inc()
+		return err
 	}
 
 	signedToken, err := u.JwtUsecase.GenerateToken(user.ID, user.Role, user.IIN)
 	if err != nil {
-		logerr := err.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		return e.Render(logerr.Code, "error.html", "Unexpected error. Please try again in several minutes")
-		// return e.String(http.StatusInternalServerError, "generate token error")
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginError).Inc()
+		return err
 	}
 
-	if err := u.JwtUsecase.InsertToken(user.ID, signedToken); err != nil {
-		logerr := err.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		return e.Render(logerr.Code, "error.html", "Unexpected error. Please try again in several minutes")
-		// return e.String(http.StatusInternalServerError, "insert error")
+	if err := u.JwtUsecase.InsertToken(e.Request().Context(), user.ID, signedToken); err != nil {
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginError).Inc()
+		return err
 	}
 
+	u.audit(e, domain.AuditLoginSuccess, user.ID, user.ID, "")
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginSuccess).Inc()
 	u.SetCookie(e, signedToken)
 	// return e.JSON(http.StatusOK, user)
 	return e.Render(http.StatusOK, "home.html", user)
@@ -129,120 +180,449 @@ func (u *UserHandler) SetCookie(e echo.Context, signedToken string) {
 	e.SetCookie(cookie)
 }
 
+// ClearCookie signs the browser out.
+func (u *UserHandler) ClearCookie(e echo.Context) {
+	e.SetCookie(&http.Cookie{Name: "access-token", Value: "", MaxAge: -1})
+}
+
 func (u *UserHandler) Registration(e echo.Context) error {
 
 	userInfo := u.ExtractCreds(e)
 	ctx := e.Request().Context()
 	if err := u.UserUsecase.CreateUserUsecase(ctx, userInfo); err != nil {
-		logerr := err.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		return e.Render(logerr.Code, "error.html", logerr.Message)
+		metrics.Signups.WithLabelValues(metrics.SignupOutcome(err)).Inc()
+		// show the form again with the input and what is wrong with it
+		form := signupForm{Username: userInfo.Username, IIN: userInfo.IIN, Email: userInfo.Email, AccountType: userInfo.AccountType}
+		if userInfo.Company != nil {
+			form.BIN, form.CompanyName = userInfo.Company.BIN, userInfo.Company.Name
+		}
+		if fields, ok := validation.As(err); ok {
+			form.Errors = fields.Localize(validation.Language(e.Request().Header.Get("Accept-Language"))).ByField()
+			return e.Render(http.StatusBadRequest, "signup.html", form)
+		}
+		if domain.KindOf(err) == domain.KindConflict {
+			form.Message = domain.AsError(err).Message
+			return e.Render(http.StatusConflict, "signup.html", form)
+		}
+		return err
+	}
+	details := "username " + userInfo.Username
+	if userInfo.Company != nil {
+		details += " for company " + userInfo.Company.BIN
 	}
+	u.audit(e, domain.AuditRegistration, 0, userInfo.ID, details)
+	metrics.Signups.WithLabelValues(metrics.SignupSuccess).Inc()
 	// return e.JSON(http.StatusCreated, "Successfully registered. Now you can log in")
 	return e.Render(http.StatusCreated, "login.html", "Successfully registered. Now you can log in")
 }
 
+// passwordCheck is the password strength request sent while the signup form is filled.
+type passwordCheck struct {
+	Password string `json:"password" form:"password"`
+	Username string `json:"username" form:"username"`
+	IIN      string `json:"iin" form:"iin"`
+}
+
+// PasswordStrength tells the signup page how the typed password fares against
+// the policy, the password is neither stored nor logged.
+func (u *UserHandler) PasswordStrength(e echo.Context) error {
+	var req passwordCheck
+	if err := e.Bind(&req); err != nil {
+		return domain.Validation(domain.CodeInvalidInput, "invalid password check", err)
+	}
+
+	report := u.UserUsecase.CheckPasswordUsecase(&domain.User{Username: req.Username, IIN: req.IIN, Password: req.Password})
+	report.Errors = report.Errors.Localize(validation.Language(e.Request().Header.Get("Accept-Language")))
+	e.Response().Header().Set("Cache-Control", "no-store")
+	// replaces the text/html default set for the pages
+	e.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	return e.JSON(http.StatusOK, report)
+}
+
 func (u *UserHandler) UpgradeRole(e echo.Context) error {
 
 	username := e.Param("username")
 	meta, ok := e.Get("user").(domain.User)
 	if !ok {
-		log.Err(domain.ErrorMetaNotFound).Msg("unauthorized")
-		return e.Render(http.StatusUnauthorized, "error.html", "access denied")
+		return domain.ErrUnauthenticated
 	}
 
 	if meta.Role != "admin" {
-		log.Log().Msg("role not admin")
-		return e.Render(http.StatusForbidden, "error.html", "access denied")
+		return domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("role %q cannot upgrade roles", meta.Role))
 	}
 	ctx := e.Request().Context()
 	if err := u.UserUsecase.UpgradeUserUsecase(ctx, username); err != nil {
-		logerr := err.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		return e.Render(http.StatusInternalServerError, "error.html", "Unexpected error. Please try again")
+		return err
 	}
+	u.audit(e, domain.AuditRoleUpgrade, meta.ID, 0, fmt.Sprintf("username %s upgraded to admin", username))
+	metrics.RoleChanges.WithLabelValues("admin").Inc()
 	// return e.String(http.StatusOK, fmt.Sprintf("User %s upgraded to administrator", username))
 	return e.Render(http.StatusOK, "error.html", fmt.Sprintf("User %s upgraded to administrator", username))
 }
 
+// ChangeStatus moves an account along its lifecycle, the sessions of an
+// account that is no longer active are revoked.
+func (u *UserHandler) ChangeStatus(e echo.Context) error {
+
+	username := e.Param("username")
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+
+	if !domain.HasPermission(meta.Role, domain.PermUserStatus) {
+		return domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("role %q cannot change account statuses", meta.Role))
+	}
+	ctx := e.Request().Context()
+	reason := e.FormValue("reason")
+	user, err := u.UserUsecase.ChangeStatusUsecase(ctx, meta.ID, username, e.FormValue("status"), reason)
+	if err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditStatusChange, meta.ID, user.ID, strings.TrimSpace(fmt.Sprintf("status set to %s %s", user.Status, reason)))
+	if user.Status != domain.StatusActive {
+		if err := u.JwtUsecase.RevokeToken(ctx, user.ID); err != nil {
+			return err
+		}
+		u.audit(e, domain.AuditTokenRevoked, meta.ID, user.ID, "account "+user.Status)
+	}
+	return e.Render(http.StatusOK, "error.html", fmt.Sprintf("User %s is now %s", user.Username, user.Status))
+}
+
+// DeleteUser deletes an account on behalf of staff, its data is erased after the grace period.
+func (u *UserHandler) DeleteUser(e echo.Context) error {
+
+	username := e.Param("username")
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+
+	if !domain.HasPermission(meta.Role, domain.PermUserDelete) {
+		return domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("role %q cannot delete accounts", meta.Role))
+	}
+	ctx := e.Request().Context()
+	reason := e.FormValue("reason")
+	user, err := u.UserUsecase.DeleteUserUsecase(ctx, meta.ID, username, reason)
+	if err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditUserDelete, meta.ID, user.ID, reason)
+	if err := u.JwtUsecase.RevokeToken(ctx, user.ID); err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditTokenRevoked, meta.ID, user.ID, "account deleted")
+	return e.Render(http.StatusOK, "error.html", fmt.Sprintf("User %s is deleted, the data is erased after the grace period", user.Username))
+}
+
+func (u *UserHandler) TokenExchange(e echo.Context) error {
+
+	params, err := e.FormParams()
+	if err != nil {
+		logging.From(e).Err(err).Msg("parse token exchange form")
+		return e.JSON(http.StatusBadRequest, map[string]string{"error": domain.ErrInvalidRequest})
+	}
+	// services authenticate with HTTP Basic or, failing that, in the form (RFC 6749 2.3.1)
+	clientID, clientSecret, ok := e.Request().BasicAuth()
+	if !ok {
+		clientID, clientSecret = params.Get("client_id"), params.Get("client_secret")
+	}
+	req := &domain.TokenExchangeRequest{
+		ClientID:           clientID,
+		ClientSecret:       clientSecret,
+		GrantType:          params.Get("grant_type"),
+		SubjectToken:       params.Get("subject_token"),
+		SubjectTokenType:   params.Get("subject_token_type"),
+		ActorToken:         params.Get("actor_token"),
+		ActorTokenType:     params.Get("actor_token_type"),
+		RequestedTokenType: params.Get("requested_token_type"),
+		Audience:           params["audience"],
+		Scope:              strings.Fields(params.Get("scope")),
+	}
+
+	e.Response().Header().Set("Cache-Control", "no-store")
+	resp, err := u.JwtUsecase.ExchangeToken(e.Request().Context(), req)
+	if err != nil {
+		// RFC 6749 error responses, not the generic error body
+		derr := domain.AsError(err)
+		logging.From(e).Err(derr.Err).Str("code", derr.Code).Msg(derr.Message)
+		status := httperror.Status(derr.Kind)
+		if derr.Code == domain.ErrInvalidClient {
+			e.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="token"`)
+		}
+		body := map[string]string{"error": derr.Code}
+		if status < http.StatusInternalServerError {
+			body["error_description"] = derr.Error()
+		}
+		return e.JSON(status, body)
+	}
+	return e.JSON(http.StatusOK, resp)
+}
+
+func (u *UserHandler) StartImpersonation(e echo.Context) error {
+
+	targetID, err := strconv.Atoi(e.Param("id"))
+	if err != nil {
+		return domain.Validation(domain.CodeInvalidInput, "Invalid ID", err)
+	}
+
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+
+	ctx := e.Request().Context()
+	target, err := u.ImpersonationUsecase.StartImpersonation(ctx, meta, int64(targetID), e.FormValue("reason"), e.RealIP(), e.Request().UserAgent())
+	if err != nil {
+		return err
+	}
+
+	u.audit(e, domain.AuditImpersonationStart, meta.ID, target.ID, e.FormValue("reason"))
+
+	signedToken, err := u.JwtUsecase.GenerateImpersonationToken(target, domain.Actor{ID: meta.ID, Role: meta.Role})
+	if err != nil {
+		return err
+	}
+	if err := u.JwtUsecase.InsertImpersonationToken(e.Request().Context(), meta.ID, signedToken); err != nil {
+		return err
+	}
+
+	u.SetCookie(e, signedToken)
+	return e.Redirect(http.StatusSeeOther, "/user/home")
+}
+
+func (u *UserHandler) StopImpersonation(e echo.Context) error {
+
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+	if meta.Actor == nil {
+		return domain.Validation(domain.CodeImpersonation, "No active impersonation", nil)
+	}
+
+	if err := u.JwtUsecase.DeleteImpersonationToken(e.Request().Context(), meta.Actor.ID); err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditTokenRevoked, meta.Actor.ID, meta.ID, "impersonation token")
+	ctx := e.Request().Context()
+	if err := u.ImpersonationUsecase.StopImpersonation(ctx, meta.Actor.ID); err != nil {
+		logging.From(e).Err(err).Msg("cannot record end of impersonation")
+	}
+	u.audit(e, domain.AuditImpersonationStop, meta.Actor.ID, meta.ID, "")
+
+	// hand the actor a fresh session of their own, or send them to sign in again
+	actor, err := u.UserUsecase.GetUserByIDUsecase(ctx, meta.Actor.ID)
+	if err != nil {
+		logging.From(e).Err(err).Msg("cannot restore actor session")
+		return e.Redirect(http.StatusSeeOther, e.Echo().Reverse("userSignInForm"))
+	}
+	signedToken, err := u.JwtUsecase.GenerateToken(actor.ID, actor.Role, actor.IIN)
+	if err != nil {
+		logging.From(e).Err(err).Msg("cannot restore actor session")
+		return e.Redirect(http.StatusSeeOther, e.Echo().Reverse("userSignInForm"))
+	}
+	if err := u.JwtUsecase.InsertToken(e.Request().Context(), actor.ID, signedToken); err != nil {
+		logging.From(e).Err(err).Msg("cannot restore actor session")
+		return e.Redirect(http.StatusSeeOther, e.Echo().Reverse("userSignInForm"))
+	}
+
+	u.SetCookie(e, signedToken)
+	return e.Redirect(http.StatusSeeOther, "/user/info/all")
+}
+
 func (u *UserHandler) ExtractCreds(c echo.Context) *domain.User {
-	return &domain.User{
-		Username: c.FormValue("username"),
-		Password: c.FormValue("password"),
-		IIN:      c.FormValue("iin"),
-		Role:     c.FormValue("role"),
+	user := &domain.User{
+		Username:    c.FormValue("username"),
+		Password:    c.FormValue("password"),
+		IIN:         c.FormValue("iin"),
+		Email:       strings.TrimSpace(c.FormValue("email")),
+		AccountType: c.FormValue("account_type"),
 	}
+	if user.AccountType == domain.AccountLegalEntity {
+		user.Company = &domain.Company{BIN: strings.TrimSpace(c.FormValue("bin")), Name: c.FormValue("company_name")}
+	}
+	return user
 }
 
 func (u *UserHandler) LoginPage(e echo.Context) error {
 	return e.Render(http.StatusOK, "login.html", nil)
 }
 
+// signupForm is the data of signup.html, the password is never sent back.
+type signupForm struct {
+	Username    string
+	IIN         string
+	Email       string
+	AccountType string
+	BIN         string
+	CompanyName string
+	// Errors holds the messages of the invalid fields by field name.
+	Errors  map[string][]string
+	Message string
+}
+
 func (u *UserHandler) RegistrationPage(e echo.Context) error {
-	return e.Render(http.StatusOK, "signup.html", nil)
+	return e.Render(http.StatusOK, "signup.html", signupForm{})
+}
+
+// passwordForm is the data of password.html, passwords are never sent back.
+type passwordForm struct {
+	// Errors holds the messages of the invalid fields by field name.
+	Errors  map[string][]string
+	Message string
+}
+
+func (u *UserHandler) ChangePasswordPage(e echo.Context) error {
+	form := passwordForm{}
+	if e.QueryParam("expired") != "" {
+		form.Message = "Your password has expired, choose a new one to continue"
+	}
+	return e.Render(http.StatusOK, "password.html", form)
+}
+
+// ChangePassword replaces the password of the signed in user. Every other
+// session of the user is revoked and this one continues with a new token.
+func (u *UserHandler) ChangePassword(e echo.Context) error {
+
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+
+	lang := validation.Language(e.Request().Header.Get("Accept-Language"))
+	next := e.FormValue("new_password")
+	if next != e.FormValue("confirm_password") {
+		var errs validation.Errors
+		errs.Add("confirm_password", validation.NewFieldError("confirm_password", validation.CodePasswordMismatch))
+		return e.Render(http.StatusBadRequest, "password.html", passwordForm{Errors: errs.Localize(lang).ByField()})
+	}
+
+	ctx := e.Request().Context()
+	user, err := u.UserUsecase.ChangePasswordUsecase(ctx, meta.ID, e.FormValue("current_password"), next)
+	if fields, ok := validation.As(err); ok {
+		return e.Render(http.StatusBadRequest, "password.html", passwordForm{Errors: fields.Localize(lang).ByField()})
+	}
+	if err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditPasswordChange, meta.ID, meta.ID, "")
+
+	// whoever knew the old password is signed out
+	if err := u.JwtUsecase.RevokeToken(ctx, user.ID); err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditTokenRevoked, meta.ID, meta.ID, "password change")
+	signedToken, err := u.JwtUsecase.GenerateToken(user.ID, user.Role, user.IIN)
+	if err != nil {
+		return err
+	}
+	if err := u.JwtUsecase.InsertToken(ctx, user.ID, signedToken); err != nil {
+		return err
+	}
+	u.SetCookie(e, signedToken)
+	return e.Render(http.StatusOK, "password.html", passwordForm{Message: "Password changed, your other sessions were signed out"})
+}
+
+// closeForm is the data of close.html, the password is never sent back.
+type closeForm struct {
+	// Errors holds the messages of the invalid fields by field name.
+	Errors map[string][]string
+}
+
+func (u *UserHandler) CloseAccountPage(e echo.Context) error {
+	return e.Render(http.StatusOK, "close.html", closeForm{})
+}
+
+// CloseAccount deletes the account of the signed in user, who is signed out
+// everywhere.
+func (u *UserHandler) CloseAccount(e echo.Context) error {
+
+	meta, ok := e.Get("user").(domain.User)
+	if !ok {
+		return domain.ErrUnauthenticated
+	}
+
+	ctx := e.Request().Context()
+	user, err := u.UserUsecase.CloseAccountUsecase(ctx, meta.ID, e.FormValue("password"))
+	if fields, ok := validation.As(err); ok {
+		lang := validation.Language(e.Request().Header.Get("Accept-Language"))
+		return e.Render(http.StatusBadRequest, "close.html", closeForm{Errors: fields.Localize(lang).ByField()})
+	}
+	if err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditUserDelete, meta.ID, meta.ID, "closed by the user")
+	if err := u.JwtUsecase.RevokeToken(ctx, user.ID); err != nil {
+		return err
+	}
+	u.audit(e, domain.AuditTokenRevoked, meta.ID, meta.ID, "account closed")
+	u.ClearCookie(e)
+	return e.Render(http.StatusOK, "login.html", "Your account is closed")
 }
 
 func (u *UserHandler) GetUserInfo(e echo.Context) error {
 
 	newID, err := strconv.Atoi(e.Param("id"))
 	if err != nil {
-		log.Err(err).Msg(err.Error())
-		return e.Render(http.StatusBadRequest, "error.html", "Invalid ID")
+		return domain.Validation(domain.CodeInvalidInput, "Invalid ID", err)
 	}
 
 	meta, ok := e.Get("user").(domain.User)
 	if !ok {
-		log.Err(domain.ErrorMetaNotFound).Msg("unauthorized")
-		return e.Render(http.StatusUnauthorized, "error.html", "access denied")
+		return domain.ErrUnauthenticated
 	}
 
 	if meta.Role != "admin" && meta.ID != int64(newID) {
-		log.Log().Msg("requesting confidentional information")
-		return e.Render(http.StatusForbidden, "error.html", "access denied")
+		return domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("user %d requesting confidential information of user %d", meta.ID, newID))
+	}
+	if meta.ID != int64(newID) {
+		u.audit(e, domain.AuditUserDataView, meta.ID, int64(newID), "user info")
 	}
 	ctx := e.Request().Context()
 	user, err1 := u.UserUsecase.GetUserByIDUsecase(ctx, int64(newID))
 	if err1 != nil {
-		logerr := err1.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		// return e.String(http.StatusBadRequest, fmt.Sprintf("user not found: %v", err)) logg
-		return e.Render(http.StatusBadRequest, "error.html", logerr.Message)
+		return err1
+	}
+	var history []domain.StatusChange
+	if domain.HasPermission(meta.Role, domain.PermUserStatus) {
+		if history, err = u.UserUsecase.StatusHistoryUsecase(ctx, user.ID); err != nil {
+			return err
+		}
 	}
 	acc, err2 := GetAccountInfo(e, user.IIN)
 	if err2 != nil {
-		logerr := err2.(*domain.LogError)
-		log.Err(logerr).Msg(logerr.Message)
+		logging.From(e).Err(err2).Msg("account info unavailable")
 		info := domain.UserInfo{
-			User: *user,
+			User:          *user,
+			StatusHistory: history,
 		}
-		return e.Render(http.StatusOK, "userinfo.html", info)
+		return e.Render(http.StatusOK, "userinfo.html", info.Present(meta))
 	}
 	info := domain.UserInfo{
-		User:     *user,
-		Accounts: acc,
+		User:          *user,
+		Accounts:      acc,
+		StatusHistory: history,
 	}
-	fmt.Println("this is user account info from transaction service  => ", acc)
-	return e.Render(http.StatusOK, "userinfo.html", info)
+	logging.From(e).Debug().Int("accounts", len(acc)).Msg("account info from transaction service")
+	return e.Render(http.StatusOK, "userinfo.html", info.Present(meta))
 }
 
 func (u *UserHandler) GetAllUserInfo(e echo.Context) error {
 
 	meta, ok := e.Get("user").(domain.User)
 	if !ok {
-		log.Err(domain.ErrorMetaNotFound).Msg("unauthorized")
-		return e.Render(http.StatusUnauthorized, "error.html", "access denied")
+		return domain.ErrUnauthenticated
 	}
 
 	if meta.Role != "admin" {
-		log.Log().Msg("requesting confidentional information")
-		return e.Render(http.StatusForbidden, "error.html", "access denied")
+		return domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("user %d requesting confidential information", meta.ID))
 	}
+	u.audit(e, domain.AuditUserDataView, meta.ID, 0, "all users info")
 	ctx := e.Request().Context()
 	users, err := u.UserUsecase.GetAllUsecase(ctx)
 	if err != nil {
-		logerr := err.(*domain.LogError)
-		log.Err(logerr.Err).Msg(logerr.Message)
-		return e.Render(logerr.Code, "error.html", "Unexpected error. Please try again")
+		return err
 	}
 
 	all := []domain.UserInfo{}
@@ -250,12 +630,11 @@ func (u *UserHandler) GetAllUserInfo(e echo.Context) error {
 	for _, user := range users {
 		acc, err1 := GetAccountInfo(e, user.IIN)
 		if err1 != nil {
-			logErr := err1.(*domain.LogError)
-			log.Err(logErr).Msg(logErr.Message)
+			logging.From(e).Err(err1).Msg("account info unavailable")
 			info := domain.UserInfo{
 				User: user,
 			}
-			all = append(all, info)
+			all = append(all, info.Present(meta))
 			continue
 			// return e.Render(http.StatusOK, "alluser.html", all)
 		}
@@ -264,45 +643,76 @@ func (u *UserHandler) GetAllUserInfo(e echo.Context) error {
 			User:     user,
 			Accounts: acc,
 		}
-		all = append(all, info)
+		all = append(all, info.Present(meta))
 	}
-	fmt.Println("this is all user from transaction service  => ", all)
+	logging.From(e).Debug().Int("users", len(all)).Msg("all users account info from transaction service")
 	return e.Render(http.StatusOK, "alluser.html", all)
 	// return e.JSON(http.StatusOK, all)
 }
 
+// audit records a security event, failures are logged and never interrupt the request.
+func (u *UserHandler) audit(e echo.Context, action string, actorID, targetID int64, details string) {
+	if u.AuditUsecase == nil {
+		return
+	}
+	if meta, ok := e.Get("user").(domain.User); ok && meta.Actor != nil && actorID == meta.ID {
+		actorID = meta.Actor.ID
+		details = strings.TrimSpace(fmt.Sprintf("%s (impersonating user %d)", details, meta.ID))
+	}
+	event := &domain.AuditEvent{
+		Action:    action,
+		ActorID:   actorID,
+		TargetID:  targetID,
+		IP:        e.RealIP(),
+		UserAgent: e.Request().UserAgent(),
+		Details:   details,
+	}
+	if err := u.AuditUsecase.RecordEvent(e.Request().Context(), event); err != nil {
+		logging.From(e).Err(err).Msg("cannot record audit event")
+	}
+}
+
+// AccountServiceURL is the base URL of the transaction service.
+var AccountServiceURL = "http://localhost:8181"
+
+// accountClient propagates the trace context to the transaction service.
+var accountClient = &http.Client{
+	Transport: otelhttp.NewTransport(metrics.Transport("transaction-service", http.DefaultTransport)),
+}
+
 func GetAccountInfo(e echo.Context, iin string) ([]domain.Accounts, error) {
 	all := []domain.Accounts{}
 
 	cookie, err := e.Cookie("access-token")
 	if err != nil {
-		return nil, &domain.LogError{"cookie not found", err, http.StatusUnauthorized}
+		return nil, domain.Unauthorized(domain.CodeUnauthenticated, "cookie not found", err)
 	}
 
-	req, err := http.NewRequest("GET", "http://localhost:8181/account/info/"+iin+"/auth", nil)
+	req, err := http.NewRequestWithContext(e.Request().Context(), "GET", AccountServiceURL+"/account/info/"+iin+"/auth", nil)
 	if err != nil {
-		return nil, &domain.LogError{"create new request error", err, http.StatusInternalServerError}
+		return nil, domain.Internal("create new request error", err)
 	}
 
 	req.AddCookie(cookie)
-	client := &http.Client{}
-	res, err := client.Do(req)
+	req.Header.Set(logging.HeaderRequestID, logging.RequestID(req.Context()))
+	res, err := accountClient.Do(req)
 	if err != nil {
-		return nil, &domain.LogError{"send request error", err, http.StatusInternalServerError}
+		return nil, &domain.Error{Kind: domain.KindInternal, Code: domain.CodeUpstream, Message: "send request error", Err: err}
 	}
 
 	resp, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, &domain.LogError{"read response body error", err, http.StatusInternalServerError}
+		return nil, &domain.Error{Kind: domain.KindInternal, Code: domain.CodeUpstream, Message: "read response body error", Err: err}
 	}
 	res.Body.Close()
 
 	if res.StatusCode != 200 {
-		return nil, &domain.LogError{"accounts not found", err, res.StatusCode}
+		return nil, domain.NotFound(domain.CodeAccountsNotFound, "accounts not found", fmt.Errorf("transaction service responded %d", res.StatusCode))
 	}
 	if err := json.Unmarshal(resp, &all); err != nil {
-		log.Log().Msg(string(resp))
-		return nil, &domain.LogError{"unmarshal response body error", err, http.StatusInternalServerError}
+		// the body carries the IIN and the balances, only its size is logged
+		logging.From(e).Debug().Stringer("iin", logging.IIN(iin)).Int("bytes", len(resp)).Msg("unexpected account info response")
+		return nil, &domain.Error{Kind: domain.KindInternal, Code: domain.CodeUpstream, Message: "unmarshal response body error", Err: err}
 	}
 	return all, nil
 }
@@ -4,7 +4,9 @@ import (
 	"github.com/bxcodec/faker"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -16,6 +18,20 @@ import (
 	utils "transaction-service/utils"
 )
 
+// testRenderer stands in for the HTML templates and writes the template name.
+type testRenderer struct{}
+
+func (testRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	_, err := io.WriteString(w, name)
+	return err
+}
+
+func newEcho() *echo.Echo {
+	e := echo.New()
+	e.Renderer = testRenderer{}
+	return e
+}
+
 func TestHome(t *testing.T) {
 
 	var mockNewUser domain.User
@@ -25,7 +41,7 @@ func TestHome(t *testing.T) {
 	mockUCase := new(mocks.UserUsecase)
 	mockUCase.On("GetUserByIDUsecase", mock.Anything, mockNewUser.ID).Return(&mockNewUser, nil)
 
-	e := echo.New()
+	e := newEcho()
 
 	req, err := http.NewRequest(echo.GET, "/user/home", strings.NewReader(""))
 	assert.NoError(t, err)
@@ -57,7 +73,7 @@ func TestRegistration(t *testing.T) {
 
 	mockUCase.On("CreateUserUsecase", mock.Anything, mockUser).Return(nil)
 
-	e := echo.New()
+	e := newEcho()
 	req, err := http.NewRequest(echo.POST, "/signup?username=nazerke&iin=940217450216&password=Qwe12@", strings.NewReader(""))
 	assert.NoError(t, err)
 
@@ -88,9 +104,9 @@ func TestSignIn(t *testing.T) {
 	assert.False(t, ok)
 	mockJWTUCase := new(mocks.JwtTokenUsecase)
 	mockJWTUCase.On("GenerateToken", mockNewUser.ID, mockNewUser.Role, mockNewUser.IIN).Return(token, nil)
-	mockJWTUCase.On("InsertToken", mockNewUser.ID, token).Return(nil)
+	mockJWTUCase.On("InsertToken", mock.Anything, mockNewUser.ID, token).Return(nil)
 
-	e := echo.New()
+	e := newEcho()
 	req, err := http.NewRequest(echo.POST, "/sigin?username="+mockNewUser.Username+"&password="+mockNewUser.Password, strings.NewReader(""))
 	assert.NoError(t, err)
 
@@ -103,12 +119,40 @@ func TestSignIn(t *testing.T) {
 		JwtUsecase:  mockJWTUCase,
 	}
 	err = handler.Signin(c)
-	require.NoError(t, err)
+	require.Error(t, err)
 
-	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, domain.KindUnauthorized, domain.KindOf(err))
+	assert.Equal(t, "incorrect username or password", domain.AsError(err).Message)
 	mockUCase.AssertExpectations(t)
 }
 
+func TestSignInUnknownUsername(t *testing.T) {
+	mockUCase := new(mocks.UserUsecase)
+	mockUCase.On("GetUserByNameUsecase", mock.Anything, "Qwe123@!").Return(nil, domain.NotFound(domain.CodeUserNotFound, "user not found", domain.ErrNotFound))
+	mockAudit := new(mocks.AuditUsecase)
+	mockAudit.On("RecordEvent", mock.Anything, mock.MatchedBy(func(event *domain.AuditEvent) bool {
+		return event.Action == domain.AuditLoginFailure && event.Details == "unknown username"
+	})).Return(nil).Once()
+
+	e := newEcho()
+	// a password typed into the username field must not reach the audit log
+	req, err := http.NewRequest(echo.POST, "/signin?username=Qwe123@!&password=Qwe123@!", strings.NewReader(""))
+	assert.NoError(t, err)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	handler := userHTTP.UserHandler{
+		UserUsecase:  mockUCase,
+		AuditUsecase: mockAudit,
+	}
+	err = handler.Signin(c)
+
+	// indistinguishable from a wrong password
+	assert.Equal(t, domain.KindUnauthorized, domain.KindOf(err))
+	assert.Equal(t, domain.CodeInvalidCredentials, domain.CodeOf(err))
+	assert.Equal(t, "incorrect username or password", domain.AsError(err).Message)
+	mockAudit.AssertExpectations(t)
+}
+
 func TestGetAllUserInfo(t *testing.T) {
 
 	var mockNewUser domain.User
@@ -121,7 +165,7 @@ func TestGetAllUserInfo(t *testing.T) {
 	mockUCase := new(mocks.UserUsecase)
 	mockUCase.On("GetAllUsecase", mock.Anything).Return(mockListUser, nil)
 
-	e := echo.New()
+	e := newEcho()
 	req, err := http.NewRequest(echo.GET, "/user/info/all", strings.NewReader(""))
 	assert.NoError(t, err)
 
@@ -150,7 +194,7 @@ func TestGetUserInfo(t *testing.T) {
 	mockUCase := new(mocks.UserUsecase)
 	mockUCase.On("GetUserByIDUsecase", mock.Anything, mockNewUser.ID).Return(&mockNewUser, nil)
 
-	e := echo.New()
+	e := newEcho()
 	req, err := http.NewRequest(echo.GET, "/user/info/"+id, strings.NewReader(""))
 	assert.NoError(t, err)
 
@@ -182,7 +226,7 @@ func TestUpgradeRole(t *testing.T) {
 	mockUCase := new(mocks.UserUsecase)
 	mockUCase.On("UpgradeUserUsecase", mock.Anything, "someuser").Return(nil)
 
-	e := echo.New()
+	e := newEcho()
 
 	req, err := http.NewRequest(echo.GET, "/user/info/someuser", strings.NewReader(""))
 	assert.NoError(t, err)
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"transaction-service/domain"
+	"transaction-service/tracing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type companyRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewCompanyRepository(Conn *pgxpool.Pool) domain.CompanyRepository {
+	return &companyRepository{Conn}
+}
+
+func (c *companyRepository) GetCompanyByID(ctx context.Context, id int64) (*domain.Company, error) {
+	ctx, span := tracing.Postgres(ctx, "companyRepository.GetCompanyByID")
+	defer span.End()
+
+	company := &domain.Company{}
+	if err := c.Conn.QueryRow(ctx, "SELECT id, bin, name, registered_at, created_at FROM companies WHERE id=$1", id).
+		Scan(&company.ID, &company.BIN, &company.Name, &company.RegisteredAt, &company.CreatedAt); err != nil {
+		return nil, tracing.Fail(span, dbError("db get company by id", err))
+	}
+	return company, nil
+}
+
+func (c *companyRepository) ListUserCompanies(ctx context.Context, userID int64) ([]domain.Membership, error) {
+	ctx, span := tracing.Postgres(ctx, "companyRepository.ListUserCompanies")
+	defer span.End()
+
+	rows, err := c.Conn.Query(ctx, `SELECT c.id, c.bin, c.name, c.registered_at, c.created_at, m.role
+		FROM company_members m JOIN companies c ON c.id = m.company_id WHERE m.user_id=$1 ORDER BY c.name`, userID)
+	if err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list user companies: %w", err))
+	}
+	defer rows.Close()
+
+	memberships := []domain.Membership{}
+	for rows.Next() {
+		var m domain.Membership
+		if err := rows.Scan(&m.Company.ID, &m.Company.BIN, &m.Company.Name, &m.Company.RegisteredAt, &m.Company.CreatedAt, &m.Role); err != nil {
+			return nil, tracing.Fail(span, fmt.Errorf("db scan user company: %w", err))
+		}
+		memberships = append(memberships, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list user companies: %w", err))
+	}
+	return memberships, nil
+}
+
+func (c *companyRepository) ListCompanyMembers(ctx context.Context, companyID int64) ([]domain.CompanyMember, error) {
+	ctx, span := tracing.Postgres(ctx, "companyRepository.ListCompanyMembers")
+	defer span.End()
+
+	rows, err := c.Conn.Query(ctx, `SELECT m.company_id, m.user_id, u.username, m.role, m.added_at
+		FROM company_members m JOIN users u ON u.id = m.user_id WHERE m.company_id=$1 ORDER BY m.added_at, m.user_id`, companyID)
+	if err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list company members: %w", err))
+	}
+	defer rows.Close()
+
+	members := []domain.CompanyMember{}
+	for rows.Next() {
+		var m domain.CompanyMember
+		if err := rows.Scan(&m.CompanyID, &m.UserID, &m.Username, &m.Role, &m.AddedAt); err != nil {
+			return nil, tracing.Fail(span, fmt.Errorf("db scan company member: %w", err))
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list company members: %w", err))
+	}
+	return members, nil
+}
+
+func (c *companyRepository) GetCompanyMember(ctx context.Context, companyID, userID int64) (*domain.CompanyMember, error) {
+	ctx, span := tracing.Postgres(ctx, "companyRepository.GetCompanyMember")
+	defer span.End()
+
+	m := &domain.CompanyMember{}
+	if err := c.Conn.QueryRow(ctx, `SELECT m.company_id, m.user_id, u.username, m.role, m.added_at
+		FROM company_members m JOIN users u ON u.id = m.user_id WHERE m.company_id=$1 AND m.user_id=$2`, companyID, userID).
+		Scan(&m.CompanyID, &m.UserID, &m.Username, &m.Role, &m.AddedAt); err != nil {
+		return nil, tracing.Fail(span, dbError("db get company member", err))
+	}
+	return m, nil
+}
+
+func (c *companyRepository) SetCompanyMember(ctx context.Context, member *domain.CompanyMember) error {
+	ctx, span := tracing.Postgres(ctx, "companyRepository.SetCompanyMember")
+	defer span.End()
+
+	if err := c.Conn.QueryRow(ctx, `INSERT INTO company_members(company_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (company_id, user_id) DO UPDATE SET role=EXCLUDED.role RETURNING added_at`,
+		member.CompanyID, member.UserID, member.Role).Scan(&member.AddedAt); err != nil {
+		return tracing.Fail(span, dbError("db set company member", err))
+	}
+	return nil
+}
+
+func (c *companyRepository) RemoveCompanyMember(ctx context.Context, companyID, userID int64) error {
+	ctx, span := tracing.Postgres(ctx, "companyRepository.RemoveCompanyMember")
+	defer span.End()
+
+	tag, err := c.Conn.Exec(ctx, "DELETE FROM company_members WHERE company_id=$1 AND user_id=$2", companyID, userID)
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("db remove company member: %w", err))
+	}
+	if tag.RowsAffected() == 0 {
+		return tracing.Fail(span, fmt.Errorf("db remove member %d of company %d: %w", userID, companyID, domain.ErrNotFound))
+	}
+	return nil
+}
+
+func (c *companyRepository) CountCompanyOwners(ctx context.Context, companyID int64) (int64, error) {
+	ctx, span := tracing.Postgres(ctx, "companyRepository.CountCompanyOwners")
+	defer span.End()
+
+	var count int64
+	if err := c.Conn.QueryRow(ctx, "SELECT count(*) FROM company_members WHERE company_id=$1 AND role=$2", companyID, domain.CompanyOwner).Scan(&count); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db count company owners: %w", err))
+	}
+	return count, nil
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"transaction-service/domain"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// dbError wraps err with the domain error usecases check for.
+func dbError(op string, err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return fmt.Errorf("%s: %w", op, domain.ErrNotFound)
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		return fmt.Errorf("%s: %w: %v", op, domain.ErrDuplicate, err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
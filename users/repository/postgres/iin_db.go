@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"transaction-service/envelope"
+	"transaction-service/tracing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// iinColumns are scanned into a storedIIN.
+const iinColumns = "iin, iin_encrypted, iin_key_id"
+
+// plaintextIIN matches the users whose IIN was stored before the encryption.
+const plaintextIIN = "iin IS NOT NULL AND iin_encrypted IS NULL"
+
+// storedIIN is an IIN as stored, sealed or still in plaintext for the rows
+// created before the encryption.
+type storedIIN struct {
+	plain  *string
+	sealed []byte
+	keyID  *string
+}
+
+func (u *userRepository) openIIN(iin storedIIN) (string, error) {
+	switch {
+	case iin.sealed != nil && iin.keyID != nil:
+		plain, err := u.keys.Open(envelope.Sealed{KeyID: *iin.keyID, Data: iin.sealed})
+		if err != nil {
+			return "", fmt.Errorf("open iin: %w", err)
+		}
+		return string(plain), nil
+	case iin.plain != nil:
+		return *iin.plain, nil
+	}
+	// erased accounts keep no IIN
+	return "", nil
+}
+
+// IINReencryptor seals the IINs still stored in plaintext and re-wraps the
+// ones sealed with a retired master key. It backs authctl reencrypt-iin and
+// is not part of the user repository.
+type IINReencryptor struct {
+	Conn *pgxpool.Pool
+	keys *envelope.Keyring
+}
+
+func NewIINReencryptor(Conn *pgxpool.Pool, keys *envelope.Keyring) *IINReencryptor {
+	return &IINReencryptor{Conn, keys}
+}
+
+// Reencrypt moves at most limit users to the active master key and returns
+// how many were updated, zero once every IIN uses it.
+func (r *IINReencryptor) Reencrypt(ctx context.Context, limit int) (int, error) {
+	ctx, span := tracing.Postgres(ctx, "IINReencryptor.Reencrypt")
+	defer span.End()
+
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db begin reencrypt iin: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "SELECT id, "+iinColumns+` FROM users
+		WHERE (`+plaintextIIN+`) OR iin_key_id<>$1
+		ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED`, r.keys.ActiveID(), limit)
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db list iin to reencrypt: %w", err))
+	}
+	type update struct {
+		id     int64
+		sealed envelope.Sealed
+		index  []byte
+	}
+	updates := []update{}
+	for rows.Next() {
+		var id int64
+		var iin storedIIN
+		if err := rows.Scan(&id, &iin.plain, &iin.sealed, &iin.keyID); err != nil {
+			rows.Close()
+			return 0, tracing.Fail(span, fmt.Errorf("db scan iin to reencrypt: %w", err))
+		}
+		if iin.sealed != nil && iin.keyID != nil {
+			// the blind index does not depend on the master key
+			sealed, _, err := r.keys.Rewrap(envelope.Sealed{KeyID: *iin.keyID, Data: iin.sealed})
+			if err != nil {
+				rows.Close()
+				return 0, tracing.Fail(span, fmt.Errorf("rewrap iin of user %d: %w", id, err))
+			}
+			updates = append(updates, update{id: id, sealed: sealed})
+			continue
+		}
+		sealed, err := r.keys.Seal([]byte(*iin.plain))
+		if err != nil {
+			rows.Close()
+			return 0, tracing.Fail(span, fmt.Errorf("seal iin of user %d: %w", id, err))
+		}
+		updates = append(updates, update{id: id, sealed: sealed, index: r.keys.Index([]byte(*iin.plain))})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db list iin to reencrypt: %w", err))
+	}
+
+	for _, u := range updates {
+		if _, err := tx.Exec(ctx, `UPDATE users SET iin=NULL, iin_encrypted=$2, iin_key_id=$3, iin_index=COALESCE($4, iin_index) WHERE id=$1`,
+			u.id, u.sealed.Data, u.sealed.KeyID, u.index); err != nil {
+			return 0, tracing.Fail(span, dbError("db reencrypt iin", err))
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db commit reencrypt iin: %w", err))
+	}
+	return len(updates), nil
+}
+
+// Plaintext counts the IINs still stored in plaintext.
+func (r *IINReencryptor) Plaintext(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Postgres(ctx, "IINReencryptor.Plaintext")
+	defer span.End()
+
+	var count int64
+	if err := r.Conn.QueryRow(ctx, "SELECT count(*) FROM users WHERE "+plaintextIIN).Scan(&count); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db count plaintext iin: %w", err))
+	}
+	return count, nil
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transaction-service/domain"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type impersonationRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewImpersonationRepository(Conn *pgxpool.Pool) domain.ImpersonationRepository {
+	return &impersonationRepository{Conn}
+}
+
+func (i *impersonationRepository) CreateImpersonation(ctx context.Context, imp *domain.Impersonation) error {
+
+	if err := i.Conn.QueryRow(ctx, `INSERT INTO impersonations(actor_id, target_id, reason, ip, user_agent, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		imp.ActorID, imp.TargetID, imp.Reason, imp.IP, imp.UserAgent, imp.StartedAt).Scan(&imp.ID); err != nil {
+		return fmt.Errorf("db create impersonation: %w", err)
+	}
+	return nil
+}
+
+func (i *impersonationRepository) EndImpersonation(ctx context.Context, actorID int64, endedAt time.Time) error {
+
+	if _, err := i.Conn.Exec(ctx, "UPDATE impersonations SET ended_at=$1 WHERE actor_id=$2 AND ended_at IS NULL",
+		endedAt, actorID); err != nil {
+		return fmt.Errorf("db end impersonation: %w", err)
+	}
+	return nil
+}
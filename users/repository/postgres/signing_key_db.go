@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transaction-service/domain"
+	"transaction-service/envelope"
+	"transaction-service/tracing"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// signingKeyRepository stores the secrets sealed with the keyring, like the IINs.
+type signingKeyRepository struct {
+	Conn *pgxpool.Pool
+	keys *envelope.Keyring
+}
+
+func NewSigningKeyRepository(Conn *pgxpool.Pool, keys *envelope.Keyring) domain.SigningKeyRepository {
+	return &signingKeyRepository{Conn, keys}
+}
+
+func (s *signingKeyRepository) ListKeys(ctx context.Context, retiredAfter time.Time) ([]domain.SigningKey, error) {
+
+	rows, err := s.Conn.Query(ctx, "SELECT id, secret, secret_key_id, created_at, retired_at FROM signing_keys WHERE retired_at IS NULL OR retired_at>$1",
+		retiredAfter)
+	if err != nil {
+		return nil, fmt.Errorf("db list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []domain.SigningKey{}
+	for rows.Next() {
+		key := domain.SigningKey{}
+		var sealed envelope.Sealed
+		if err := rows.Scan(&key.ID, &sealed.Data, &sealed.KeyID, &key.CreatedAt, &key.RetiredAt); err != nil {
+			return nil, err
+		}
+		if key.Secret, err = s.keys.Open(sealed); err != nil {
+			return nil, fmt.Errorf("open signing key %s: %w", key.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *signingKeyRepository) CreateKey(ctx context.Context, key *domain.SigningKey) error {
+
+	sealed, err := s.keys.Seal(key.Secret)
+	if err != nil {
+		return fmt.Errorf("seal signing key %s: %w", key.ID, err)
+	}
+	if _, err := s.Conn.Exec(ctx, "INSERT INTO signing_keys(id, secret, secret_key_id, created_at) VALUES ($1, $2, $3, $4)",
+		key.ID, sealed.Data, sealed.KeyID, key.CreatedAt); err != nil {
+		return fmt.Errorf("db create signing key: %w", err)
+	}
+	return nil
+}
+
+func (s *signingKeyRepository) RetireKeys(ctx context.Context, exceptID string, retiredAt time.Time) error {
+
+	if _, err := s.Conn.Exec(ctx, "UPDATE signing_keys SET retired_at=$1 WHERE retired_at IS NULL AND id<>$2",
+		retiredAt, exceptID); err != nil {
+		return fmt.Errorf("db retire signing keys: %w", err)
+	}
+	return nil
+}
+
+func (s *signingKeyRepository) DeleteRetiredKeys(ctx context.Context, retiredBefore time.Time) error {
+
+	if _, err := s.Conn.Exec(ctx, "DELETE FROM signing_keys WHERE retired_at<$1", retiredBefore); err != nil {
+		return fmt.Errorf("db delete signing keys: %w", err)
+	}
+	return nil
+}
+
+// SigningKeyReencryptor re-wraps the signing secrets sealed with a retired
+// master key. It backs authctl reencrypt-iin with IINReencryptor and is not
+// part of the signing key repository.
+type SigningKeyReencryptor struct {
+	Conn *pgxpool.Pool
+	keys *envelope.Keyring
+}
+
+func NewSigningKeyReencryptor(Conn *pgxpool.Pool, keys *envelope.Keyring) *SigningKeyReencryptor {
+	return &SigningKeyReencryptor{Conn, keys}
+}
+
+// Reencrypt moves every signing secret to the active master key and returns
+// how many were updated. There are few keys, they are updated in one
+// transaction.
+func (r *SigningKeyReencryptor) Reencrypt(ctx context.Context) (int, error) {
+	ctx, span := tracing.Postgres(ctx, "SigningKeyReencryptor.Reencrypt")
+	defer span.End()
+
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db begin reencrypt signing keys: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "SELECT id, secret, secret_key_id FROM signing_keys WHERE secret_key_id<>$1 FOR UPDATE", r.keys.ActiveID())
+	if err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db list signing keys to reencrypt: %w", err))
+	}
+	updates := map[string]envelope.Sealed{}
+	for rows.Next() {
+		var id string
+		var sealed envelope.Sealed
+		if err := rows.Scan(&id, &sealed.Data, &sealed.KeyID); err != nil {
+			rows.Close()
+			return 0, tracing.Fail(span, fmt.Errorf("db scan signing key to reencrypt: %w", err))
+		}
+		if updates[id], _, err = r.keys.Rewrap(sealed); err != nil {
+			rows.Close()
+			return 0, tracing.Fail(span, fmt.Errorf("rewrap signing key %s: %w", id, err))
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db list signing keys to reencrypt: %w", err))
+	}
+
+	for id, sealed := range updates {
+		if _, err := tx.Exec(ctx, "UPDATE signing_keys SET secret=$2, secret_key_id=$3 WHERE id=$1", id, sealed.Data, sealed.KeyID); err != nil {
+			return 0, tracing.Fail(span, fmt.Errorf("db reencrypt signing key %s: %w", id, err))
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db commit reencrypt signing keys: %w", err))
+	}
+	return len(updates), nil
+}
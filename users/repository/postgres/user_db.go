@@ -3,92 +3,471 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"time"
 	"transaction-service/domain"
+	"transaction-service/envelope"
+	"transaction-service/tracing"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type userRepository struct {
 	Conn *pgxpool.Pool
+	// keys seal the IINs, which are looked up by their blind index.
+	keys *envelope.Keyring
 }
 
-func NewUserRepository(Conn *pgxpool.Pool) domain.UserRepository {
-	return &userRepository{Conn}
+func NewUserRepository(Conn *pgxpool.Pool, keys *envelope.Keyring) domain.UserRepository {
+	return &userRepository{Conn, keys}
 }
 
+// CreateUser inserts the user and sets its ID, the initial status starts the
+// status history. The company of a legal entity
+// account is created in the same transaction with the user as its owner.
 func (u *userRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	ctx, span := tracing.Postgres(ctx, "userRepository.CreateUser")
+	defer span.End()
 
-	if _, err := u.Conn.Exec(ctx, "INSERT INTO users(iin, username, password, role, registerdate) VALUES ($1, $2, $3, $4, $5)",
-		user.IIN, user.Username, user.Password, user.Role, user.RegisterDate); err != nil {
-		return err
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("db begin create user: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	sealed, err := u.keys.Seal([]byte(user.IIN))
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("seal iin: %w", err))
+	}
+	if err := tx.QueryRow(ctx, `INSERT INTO users(iin_encrypted, iin_key_id, iin_index, username, password, role, registerdate, email, account_type, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10) RETURNING id`,
+		sealed.Data, sealed.KeyID, u.keys.Index([]byte(user.IIN)), user.Username, user.Password, user.Role, user.RegisterDate, user.Email, user.AccountType, user.Status).Scan(&user.ID); err != nil {
+		return tracing.Fail(span, dbError("db create user", err))
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO user_status_history(user_id, from_status, to_status, reason) VALUES ($1, '', $2, 'registration')",
+		user.ID, user.Status); err != nil {
+		return tracing.Fail(span, fmt.Errorf("db insert status history: %w", err))
+	}
+	if company := user.Company; company != nil {
+		if err := tx.QueryRow(ctx, "INSERT INTO companies(bin, name, registered_at) VALUES ($1, $2, $3) RETURNING id, created_at",
+			company.BIN, company.Name, company.RegisteredAt).Scan(&company.ID, &company.CreatedAt); err != nil {
+			return tracing.Fail(span, dbError("db create company", err))
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO company_members(company_id, user_id, role) VALUES ($1, $2, $3)",
+			company.ID, user.ID, domain.CompanyOwner); err != nil {
+			return tracing.Fail(span, dbError("db add company owner", err))
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return tracing.Fail(span, fmt.Errorf("db commit create user: %w", err))
 	}
 	return nil
 }
 
 func (u *userRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.GetUserByID")
+	defer span.End()
 
 	user := &domain.User{}
+	var iin storedIIN
 
-	if err := u.Conn.QueryRow(ctx, "SELECT id, iin, username, role, registerdate FROM users WHERE id=$1", id).
-		Scan(&user.ID, &user.IIN, &user.Username, &user.Role, &user.RegisterDate); err != nil {
-		return nil, err
+	if err := u.Conn.QueryRow(ctx, "SELECT id, "+iinColumns+", username, COALESCE(email, ''), role, registerdate, status, account_type, deleted_at FROM users WHERE id=$1", id).
+		Scan(&user.ID, &iin.plain, &iin.sealed, &iin.keyID, &user.Username, &user.Email, &user.Role, &user.RegisterDate, &user.Status, &user.AccountType, &user.DeletedAt); err != nil {
+		return nil, tracing.Fail(span, dbError("db get user by id", err))
+	}
+	var err error
+	if user.IIN, err = u.openIIN(iin); err != nil {
+		return nil, tracing.Fail(span, err)
 	}
-
 	return user, nil
 }
 
 func (u *userRepository) GetUserByIIN(ctx context.Context, iin string) (*domain.User, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.GetUserByIIN")
+	defer span.End()
 
 	user := &domain.User{}
+	var stored storedIIN
 
-	if err := u.Conn.QueryRow(ctx, "SELECT id, iin, username, password FROM users WHERE iin=$1", iin).
-		Scan(&user.ID, &user.IIN, &user.Username, &user.Password); err != nil {
-		return nil, err
+	// the plaintext column only matches the rows authctl reencrypt-iin has not sealed yet
+	if err := u.Conn.QueryRow(ctx, "SELECT id, "+iinColumns+", username, password, status FROM users WHERE iin_index=$1 OR iin=$2",
+		u.keys.Index([]byte(iin)), iin).
+		Scan(&user.ID, &stored.plain, &stored.sealed, &stored.keyID, &user.Username, &user.Password, &user.Status); err != nil {
+		return nil, tracing.Fail(span, dbError("db get user by iin", err))
+	}
+	var err error
+	if user.IIN, err = u.openIIN(stored); err != nil {
+		return nil, tracing.Fail(span, err)
 	}
-
 	return user, nil
 }
 
 func (u *userRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.GetUserByUsername")
+	defer span.End()
 
 	user := &domain.User{}
+	var iin storedIIN
 
-	if err := u.Conn.QueryRow(ctx, "SELECT id, iin, username, password, role, registerDate FROM users WHERE username=$1", username).
-		Scan(&user.ID, &user.IIN, &user.Username, &user.Password, &user.Role, &user.RegisterDate); err != nil {
-		return nil, err
+	if err := u.Conn.QueryRow(ctx, "SELECT id, "+iinColumns+", username, COALESCE(email, ''), password, role, registerDate, status, account_type, deleted_at FROM users WHERE username=$1", username).
+		Scan(&user.ID, &iin.plain, &iin.sealed, &iin.keyID, &user.Username, &user.Email, &user.Password, &user.Role, &user.RegisterDate, &user.Status, &user.AccountType, &user.DeletedAt); err != nil {
+		return nil, tracing.Fail(span, dbError("db get user by username", err))
+	}
+	var err error
+	if user.IIN, err = u.openIIN(iin); err != nil {
+		return nil, tracing.Fail(span, err)
 	}
 	return user, nil
 }
 
 func (u *userRepository) GetAllUsers(ctx context.Context) ([]domain.User, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.GetAllUsers")
+	defer span.End()
 
 	user := domain.User{}
 	users := []domain.User{}
+	var iin storedIIN
 
-	rows, err := u.Conn.Query(ctx, "SELECT id, iin, username, role, registerdate FROM users")
+	rows, err := u.Conn.Query(ctx, "SELECT id, "+iinColumns+", username, role, registerdate, status, deleted_at FROM users WHERE erased_at IS NULL")
 	if err != nil {
-		return nil, err
+		return nil, tracing.Fail(span, err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		if err := rows.Scan(&user.ID, &user.IIN, &user.Username, &user.Role, &user.RegisterDate); err != nil {
-			return nil, err
+		if err := rows.Scan(&user.ID, &iin.plain, &iin.sealed, &iin.keyID, &user.Username, &user.Role, &user.RegisterDate, &user.Status, &user.DeletedAt); err != nil {
+			return nil, tracing.Fail(span, err)
+		}
+		if user.IIN, err = u.openIIN(iin); err != nil {
+			return nil, tracing.Fail(span, err)
 		}
 		users = append(users, user)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, tracing.Fail(span, err)
 	}
 	return users, nil
 }
 
 func (u *userRepository) UpgradeUserRepo(ctx context.Context, username string) error {
+	ctx, span := tracing.Postgres(ctx, "userRepository.UpgradeUserRepo")
+	defer span.End()
 
 	if _, err := u.Conn.Exec(ctx, "UPDATE users SET role=$1 WHERE username=$2",
 		"admin", username); err != nil {
-		return fmt.Errorf("db upgrade: %w", err)
+		return tracing.Fail(span, fmt.Errorf("db upgrade: %w", err))
+	}
+	return nil
+}
+
+func (u *userRepository) CountUsersByRole(ctx context.Context, role string) (int64, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.CountUsersByRole")
+	defer span.End()
+
+	var count int64
+	if err := u.Conn.QueryRow(ctx, "SELECT count(*) FROM users WHERE role=$1", role).Scan(&count); err != nil {
+		return 0, tracing.Fail(span, fmt.Errorf("db count users: %w", err))
+	}
+	return count, nil
+}
+
+// UpdatePasswordRepo replaces the password of a user, the previous one is moved
+// to the history which is pruned to the keep most recent entries.
+func (u *userRepository) UpdatePasswordRepo(ctx context.Context, id int64, password string, keep int) error {
+	ctx, span := tracing.Postgres(ctx, "userRepository.UpdatePasswordRepo")
+	defer span.End()
+
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("db begin update password: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	if keep > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO password_history(user_id, password, changed_at) SELECT id, password, now() FROM users WHERE id=$1", id); err != nil {
+			return tracing.Fail(span, fmt.Errorf("db insert password history: %w", err))
+		}
+	}
+	tag, err := tx.Exec(ctx, "UPDATE users SET password=$1, password_changed_at=now() WHERE id=$2", password, id)
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("db update password: %w", err))
+	}
+	if tag.RowsAffected() == 0 {
+		return tracing.Fail(span, fmt.Errorf("db update password of user %d: %w", id, domain.ErrNotFound))
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM password_history WHERE user_id=$1 AND id NOT IN
+		(SELECT id FROM password_history WHERE user_id=$1 ORDER BY changed_at DESC, id DESC LIMIT $2)`, id, keep); err != nil {
+		return tracing.Fail(span, fmt.Errorf("db prune password history: %w", err))
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return tracing.Fail(span, fmt.Errorf("db commit update password: %w", err))
+	}
+	return nil
+}
+
+// GetPasswordRepo returns the password hash of a user.
+func (u *userRepository) GetPasswordRepo(ctx context.Context, id int64) (string, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.GetPasswordRepo")
+	defer span.End()
+
+	var password string
+	if err := u.Conn.QueryRow(ctx, "SELECT password FROM users WHERE id=$1", id).Scan(&password); err != nil {
+		return "", tracing.Fail(span, dbError("db get password", err))
+	}
+	return password, nil
+}
+
+// GetPasswordChangedAtRepo returns when the password of a user was last set.
+func (u *userRepository) GetPasswordChangedAtRepo(ctx context.Context, id int64) (time.Time, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.GetPasswordChangedAtRepo")
+	defer span.End()
+
+	var changedAt time.Time
+	if err := u.Conn.QueryRow(ctx, "SELECT password_changed_at FROM users WHERE id=$1", id).Scan(&changedAt); err != nil {
+		return time.Time{}, tracing.Fail(span, dbError("db get password changed at", err))
+	}
+	return changedAt, nil
+}
+
+// ListPasswordHistoryRepo returns the limit most recent previous password hashes of a user.
+func (u *userRepository) ListPasswordHistoryRepo(ctx context.Context, id int64, limit int) ([]string, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.ListPasswordHistoryRepo")
+	defer span.End()
+
+	rows, err := u.Conn.Query(ctx, "SELECT password FROM password_history WHERE user_id=$1 ORDER BY changed_at DESC, id DESC LIMIT $2", id, limit)
+	if err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list password history: %w", err))
+	}
+	defer rows.Close()
+
+	passwords := []string{}
+	for rows.Next() {
+		var password string
+		if err := rows.Scan(&password); err != nil {
+			return nil, tracing.Fail(span, fmt.Errorf("db list password history: %w", err))
+		}
+		passwords = append(passwords, password)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list password history: %w", err))
+	}
+	return passwords, nil
+}
+
+func (u *userRepository) SetRoleRepo(ctx context.Context, username, role string) error {
+	ctx, span := tracing.Postgres(ctx, "userRepository.SetRoleRepo")
+	defer span.End()
+
+	if _, err := u.Conn.Exec(ctx, "UPDATE users SET role=$1 WHERE username=$2", role, username); err != nil {
+		return tracing.Fail(span, fmt.Errorf("db set role: %w", err))
+	}
+	return nil
+}
+
+// SetStatusRepo changes the status of a user and records the change in the
+// history. A closed account is deleted, its grace period starts.
+func (u *userRepository) SetStatusRepo(ctx context.Context, change *domain.StatusChange) error {
+	ctx, span := tracing.Postgres(ctx, "userRepository.SetStatusRepo")
+	defer span.End()
+
+	update := "UPDATE users SET status=$1 WHERE id=$2 AND status=$3"
+	if change.To == domain.StatusClosed {
+		update = "UPDATE users SET status=$1, deleted_at=COALESCE(deleted_at, now()) WHERE id=$2 AND status=$3"
+	}
+	if err := u.setStatus(ctx, change, update); err != nil {
+		return tracing.Fail(span, err)
+	}
+	return nil
+}
+
+// DeleteUserRepo closes the account and starts the grace period before the erasure.
+func (u *userRepository) DeleteUserRepo(ctx context.Context, change *domain.StatusChange) error {
+	ctx, span := tracing.Postgres(ctx, "userRepository.DeleteUserRepo")
+	defer span.End()
+
+	if err := u.setStatus(ctx, change, "UPDATE users SET status=$1, deleted_at=now() WHERE id=$2 AND status=$3 AND deleted_at IS NULL"); err != nil {
+		return tracing.Fail(span, err)
+	}
+	return nil
+}
+
+// setStatus runs update, which moves the user from change.From to change.To,
+// and records the change in the same transaction.
+func (u *userRepository) setStatus(ctx context.Context, change *domain.StatusChange, update string) error {
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("db begin set status: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// the status is compared so concurrent changes cannot skip a transition
+	tag, err := tx.Exec(ctx, update, change.To, change.UserID, change.From)
+	if err != nil {
+		return fmt.Errorf("db set status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("db set status of user %d from %s: %w", change.UserID, change.From, domain.ErrNotFound)
+	}
+	if err := tx.QueryRow(ctx, `INSERT INTO user_status_history(user_id, from_status, to_status, actor_id, reason)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5) RETURNING id, changed_at`,
+		change.UserID, change.From, change.To, change.ActorID, change.Reason).Scan(&change.ID, &change.ChangedAt); err != nil {
+		return fmt.Errorf("db insert status history: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("db commit set status: %w", err)
+	}
+	return nil
+}
+
+// ListStatusHistoryRepo returns the status changes of a user, the most recent first.
+func (u *userRepository) ListStatusHistoryRepo(ctx context.Context, id int64) ([]domain.StatusChange, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.ListStatusHistoryRepo")
+	defer span.End()
+
+	rows, err := u.Conn.Query(ctx, `SELECT id, user_id, from_status, to_status, COALESCE(actor_id, 0), reason, changed_at
+		FROM user_status_history WHERE user_id=$1 ORDER BY changed_at DESC, id DESC`, id)
+	if err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list status history: %w", err))
+	}
+	defer rows.Close()
+
+	changes := []domain.StatusChange{}
+	for rows.Next() {
+		var c domain.StatusChange
+		if err := rows.Scan(&c.ID, &c.UserID, &c.From, &c.To, &c.ActorID, &c.Reason, &c.ChangedAt); err != nil {
+			return nil, tracing.Fail(span, fmt.Errorf("db scan status history: %w", err))
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list status history: %w", err))
+	}
+	return changes, nil
+}
+
+func (u *userRepository) ListErasableRepo(ctx context.Context, deletedBefore time.Time, limit int) ([]int64, error) {
+	ctx, span := tracing.Postgres(ctx, "userRepository.ListErasableRepo")
+	defer span.End()
+
+	rows, err := u.Conn.Query(ctx, "SELECT id FROM users WHERE deleted_at < $1 AND erased_at IS NULL ORDER BY deleted_at LIMIT $2", deletedBefore, limit)
+	if err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list erasable users: %w", err))
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, tracing.Fail(span, fmt.Errorf("db scan erasable user: %w", err))
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, tracing.Fail(span, fmt.Errorf("db list erasable users: %w", err))
+	}
+	return ids, nil
+}
+
+// EraseUserRepo pseudonymizes a deleted user and drops the data kept only to
+// serve the account. The row stays so the audit log, which is the legal record
+// and hash chained, keeps pointing to a user ID: the records about the user
+// keep their links but lose their details, address and browser. A company
+// the user owned alone passes to its longest standing member, or is deleted
+// when the user was its only member.
+func (u *userRepository) EraseUserRepo(ctx context.Context, id int64) error {
+	ctx, span := tracing.Postgres(ctx, "userRepository.EraseUserRepo")
+	defer span.End()
+
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return tracing.Fail(span, fmt.Errorf("db begin erase user: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	var username string
+	err = tx.QueryRow(ctx, "SELECT username FROM users WHERE id=$1 AND deleted_at IS NOT NULL AND erased_at IS NULL FOR UPDATE", id).Scan(&username)
+	if err != nil {
+		return tracing.Fail(span, dbError(fmt.Sprintf("db erase user %d", id), err))
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET username='erased-'||id, iin=NULL, iin_encrypted=NULL, iin_key_id=NULL, iin_index=NULL, email=NULL, password='', erased_at=now()
+		WHERE id=$1`, id); err != nil {
+		return tracing.Fail(span, fmt.Errorf("db erase user: %w", err))
+	}
+	if err := eraseCompanies(ctx, tx, id); err != nil {
+		return tracing.Fail(span, err)
+	}
+
+	// records without a user ID, e.g. a failed login, name the user only by username
+	mentioned := `\y` + regexp.QuoteMeta(username) + `\y`
+	for _, erase := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"DELETE FROM password_history WHERE user_id=$1", []interface{}{id}},
+		// the transitions stay, the reasons may name the person
+		{"UPDATE user_status_history SET reason='' WHERE user_id=$1", []interface{}{id}},
+		{"UPDATE impersonations SET ip='', user_agent='' WHERE $1 IN (actor_id, target_id)", []interface{}{id}},
+		{redactAudit("details", "$1 IN (actor_id, target_id) OR details ~ $2"), []interface{}{id, mentioned}},
+		{redactAudit("ip", "actor_id=$1"), []interface{}{id}},
+		{redactAudit("user_agent", "actor_id=$1"), []interface{}{id}},
+	} {
+		if _, err := tx.Exec(ctx, erase.query, erase.args...); err != nil {
+			return tracing.Fail(span, fmt.Errorf("db erase user data: %w", err))
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return tracing.Fail(span, fmt.Errorf("db commit erase user: %w", err))
+	}
+	return nil
+}
+
+// redactAudit returns the statement erasing a personal field of the audit
+// records matching where. Like domain.AuditEvent.Redact it keeps the salted
+// digest of the value and drops the salt, so the chain still verifies.
+func redactAudit(field, where string) string {
+	return fmt.Sprintf(`UPDATE audit_log SET %[1]s='', salts=salts-'%[1]s',
+			redactions=redactions||jsonb_build_object('%[1]s', encode(sha256(convert_to(COALESCE(salts->>'%[1]s', '')||%[1]s, 'UTF8')), 'hex'))
+		WHERE %[1]s<>'' AND (%[2]s)`, field, where)
+}
+
+// eraseCompanies removes the user from the companies, handing each company the
+// user owned alone to the member added first and deleting those left empty.
+func eraseCompanies(ctx context.Context, tx pgx.Tx, id int64) error {
+	if _, err := tx.Exec(ctx, `UPDATE company_members m SET role=$2 FROM (
+			SELECT DISTINCT ON (o.company_id) o.company_id, o.user_id FROM company_members o
+			WHERE o.user_id<>$1
+				AND o.company_id IN (SELECT company_id FROM company_members WHERE user_id=$1 AND role=$2)
+				AND NOT EXISTS (SELECT 1 FROM company_members x WHERE x.company_id=o.company_id AND x.user_id<>$1 AND x.role=$2)
+			ORDER BY o.company_id, o.added_at, o.user_id
+		) heir
+		WHERE m.company_id=heir.company_id AND m.user_id=heir.user_id`, id, domain.CompanyOwner); err != nil {
+		return fmt.Errorf("db hand over companies: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, "DELETE FROM company_members WHERE user_id=$1 RETURNING company_id", id)
+	if err != nil {
+		return fmt.Errorf("db erase company members: %w", err)
+	}
+	companies := []int64{}
+	for rows.Next() {
+		var companyID int64
+		if err := rows.Scan(&companyID); err != nil {
+			rows.Close()
+			return fmt.Errorf("db scan erased company member: %w", err)
+		}
+		companies = append(companies, companyID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("db erase company members: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM companies c WHERE c.id = ANY($1)
+		AND NOT EXISTS (SELECT 1 FROM company_members m WHERE m.company_id=c.id)`, companies); err != nil {
+		return fmt.Errorf("db delete empty companies: %w", err)
 	}
 	return nil
 }
@@ -24,7 +24,7 @@ func TestCreateUser(t *testing.T) {
 	columns := []string{"iin", "username", "password", "role", "registerdate"}
 	pgxRows := pgxpoolmock.NewRows(columns).AddRow("940217450216", "nazerke", "qwerty", "admin", "").ToPgxRows()
 	mockPool.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).Return(pgxRows, nil)
-	userRepo := repo.NewUserRepository(mockPool)
+	userRepo := repo.NewUserRepository(mockPool, nil)
 
 	// when
 	actualReq, err := userRepo.GetUserByID(1)
@@ -1,9 +1,11 @@
 package redis
 
 import (
+	"context"
 	"time"
 
 	"transaction-service/domain"
+	"transaction-service/tracing"
 
 	"github.com/go-redis/redis"
 )
@@ -16,17 +18,63 @@ func NewRedisRepo(cl *redis.Client) domain.JwtTokenRepo {
 	return &redisRepo{Client: cl}
 }
 
-func (r *redisRepo) InsertTokenRepo(key, token string, ttl time.Duration) error {
-	if err := r.Client.Set(key, token, ttl).Err(); err != nil {
-		return err
+func (r *redisRepo) InsertTokenRepo(ctx context.Context, key, token string, ttl time.Duration) error {
+	ctx, span := tracing.Redis(ctx, "redisRepo.InsertTokenRepo")
+	defer span.End()
+
+	if err := r.Client.WithContext(ctx).Set(key, token, ttl).Err(); err != nil {
+		return tracing.Fail(span, err)
 	}
 	return nil
 }
 
-func (r *redisRepo) FindTokenRepo(key, token string) (bool, error) {
-	value, err := r.Client.Get(key).Result()
-	if err != nil {
+func (r *redisRepo) FindTokenRepo(ctx context.Context, key, token string) (bool, error) {
+	ctx, span := tracing.Redis(ctx, "redisRepo.FindTokenRepo")
+	defer span.End()
+
+	value, err := r.Client.WithContext(ctx).Get(key).Result()
+	if err == redis.Nil {
+		// no session under key, the token was revoked or has expired
 		return false, err
 	}
+	if err != nil {
+		return false, tracing.Fail(span, err)
+	}
 	return value == token, nil
 }
+
+func (r *redisRepo) DeleteTokenRepo(ctx context.Context, key string) error {
+	ctx, span := tracing.Redis(ctx, "redisRepo.DeleteTokenRepo")
+	defer span.End()
+
+	if err := r.Client.WithContext(ctx).Del(key).Err(); err != nil {
+		return tracing.Fail(span, err)
+	}
+	return nil
+}
+
+func (r *redisRepo) ListTokensRepo(ctx context.Context, pattern string) (map[string]time.Duration, error) {
+	ctx, span := tracing.Redis(ctx, "redisRepo.ListTokensRepo")
+	defer span.End()
+
+	client := r.Client.WithContext(ctx)
+	tokens := map[string]time.Duration{}
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(cursor, pattern, 100).Result()
+		if err != nil {
+			return nil, tracing.Fail(span, err)
+		}
+		for _, key := range keys {
+			ttl, err := client.TTL(key).Result()
+			if err != nil {
+				return nil, tracing.Fail(span, err)
+			}
+			tokens[key] = ttl
+		}
+		if next == 0 {
+			return tokens, nil
+		}
+		cursor = next
+	}
+}
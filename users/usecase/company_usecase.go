@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"transaction-service/domain"
+	"transaction-service/logging"
+)
+
+type companyUsecase struct {
+	userRepo       domain.UserRepository
+	companyRepo    domain.CompanyRepository
+	timeoutContext time.Duration
+}
+
+func NewCompanyUsecase(userRepo domain.UserRepository, companyRepo domain.CompanyRepository, time time.Duration) domain.CompanyUsecase {
+	return &companyUsecase{userRepo: userRepo, companyRepo: companyRepo, timeoutContext: time}
+}
+
+// GetCompany returns the company and its members to one of its members.
+func (c *companyUsecase) GetCompany(ctx context.Context, actor domain.User, id int64) (*domain.CompanyProfile, error) {
+	context, cancel := context.WithTimeout(ctx, c.timeoutContext)
+	defer cancel()
+
+	role, err := c.authorize(context, actor, id, domain.PermCompanyRead)
+	if err != nil {
+		return nil, err
+	}
+	company, err := c.companyRepo.GetCompanyByID(context, id)
+	if err != nil {
+		return nil, companyLookupError(err)
+	}
+	members, err := c.companyRepo.ListCompanyMembers(context, id)
+	if err != nil {
+		return nil, domain.Internal("cannot load company members", err)
+	}
+	return &domain.CompanyProfile{Company: *company, Members: members, Role: role}, nil
+}
+
+func (c *companyUsecase) ListUserCompanies(ctx context.Context, userID int64) ([]domain.Membership, error) {
+	context, cancel := context.WithTimeout(ctx, c.timeoutContext)
+	defer cancel()
+
+	memberships, err := c.companyRepo.ListUserCompanies(context, userID)
+	if err != nil {
+		return nil, domain.Internal("cannot load companies", err)
+	}
+	return memberships, nil
+}
+
+// SetCompanyMember adds a user to the company or changes the role of a member.
+func (c *companyUsecase) SetCompanyMember(ctx context.Context, actor domain.User, companyID int64, username, role string) (*domain.CompanyMember, error) {
+	context, cancel := context.WithTimeout(ctx, c.timeoutContext)
+	defer cancel()
+
+	if !domain.ValidCompanyRole(role) {
+		return nil, domain.Validation(domain.CodeUnknownRole, "unknown company role "+role, nil)
+	}
+	actorRole, err := c.authorize(context, actor, companyID, domain.PermCompanyMembers)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.userRepo.GetUserByUsername(context, username)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	current, err := c.companyRepo.GetCompanyMember(context, companyID, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.Internal("cannot load company member", err)
+	}
+	if role == domain.CompanyOwner || (current != nil && current.Role == domain.CompanyOwner) {
+		if actorRole != domain.CompanyOwner {
+			return nil, domain.Forbidden(domain.CodeAccessDenied, "only an owner can grant or take the owner role",
+				fmt.Errorf("%s %d of company %d sets %s to %s", actorRole, actor.ID, companyID, username, role))
+		}
+	}
+	if current != nil && current.Role == domain.CompanyOwner && role != domain.CompanyOwner {
+		if err := c.keepOwner(context, companyID); err != nil {
+			return nil, err
+		}
+	}
+
+	member := &domain.CompanyMember{CompanyID: companyID, UserID: user.ID, Username: user.Username, Role: role}
+	if err := c.companyRepo.SetCompanyMember(context, member); err != nil {
+		return nil, domain.Internal("cannot set company member", err)
+	}
+	logging.Ctx(ctx).Info().Int64("company", companyID).Int64("actor", actor.ID).Int64("member", user.ID).Str("role", role).Msg("company member set")
+	return member, nil
+}
+
+// RemoveCompanyMember takes a user out of the company, members may always leave.
+func (c *companyUsecase) RemoveCompanyMember(ctx context.Context, actor domain.User, companyID, userID int64) error {
+	context, cancel := context.WithTimeout(ctx, c.timeoutContext)
+	defer cancel()
+
+	perm := domain.PermCompanyMembers
+	if actor.ID == userID {
+		perm = domain.PermCompanyRead
+	}
+	actorRole, err := c.authorize(context, actor, companyID, perm)
+	if err != nil {
+		return err
+	}
+	member, err := c.companyRepo.GetCompanyMember(context, companyID, userID)
+	if err != nil {
+		return memberLookupError(err)
+	}
+	if member.Role == domain.CompanyOwner {
+		if actorRole != domain.CompanyOwner {
+			return domain.Forbidden(domain.CodeAccessDenied, "only an owner can remove an owner",
+				fmt.Errorf("%s %d of company %d removes owner %d", actorRole, actor.ID, companyID, userID))
+		}
+		if err := c.keepOwner(context, companyID); err != nil {
+			return err
+		}
+	}
+
+	if err := c.companyRepo.RemoveCompanyMember(context, companyID, userID); err != nil {
+		return memberLookupError(err)
+	}
+	logging.Ctx(ctx).Info().Int64("company", companyID).Int64("actor", actor.ID).Int64("member", userID).Msg("company member removed")
+	return nil
+}
+
+// authorize returns the company role of actor, who must have perm in the
+// company. Companies the actor is not a member of are reported as not found.
+func (c *companyUsecase) authorize(ctx context.Context, actor domain.User, companyID int64, perm domain.Permission) (string, error) {
+	member, err := c.companyRepo.GetCompanyMember(ctx, companyID, actor.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", domain.NotFound(domain.CodeCompanyNotFound, "company not found", fmt.Errorf("user %d is not a member of company %d", actor.ID, companyID))
+	}
+	if err != nil {
+		return "", domain.Internal("cannot load company member", err)
+	}
+	if !domain.HasCompanyPermission(member.Role, perm) {
+		return "", domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("company role %q lacks %s", member.Role, perm))
+	}
+	return member.Role, nil
+}
+
+// keepOwner fails when the company would be left without an owner.
+func (c *companyUsecase) keepOwner(ctx context.Context, companyID int64) error {
+	owners, err := c.companyRepo.CountCompanyOwners(ctx, companyID)
+	if err != nil {
+		return domain.Internal("cannot count company owners", err)
+	}
+	if owners <= 1 {
+		return domain.Conflict(domain.CodeCompanyOwner, "a company must keep at least one owner", nil)
+	}
+	return nil
+}
+
+func companyLookupError(err error) error {
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.NotFound(domain.CodeCompanyNotFound, "company not found", err)
+	}
+	return domain.Internal("cannot load company", err)
+}
+
+func memberLookupError(err error) error {
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.NotFound(domain.CodeUserNotFound, "company member not found", err)
+	}
+	return domain.Internal("cannot load company member", err)
+}
@@ -0,0 +1,121 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	ucase "transaction-service/users/usecase"
+)
+
+func TestGetCompany(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockCompanyRepo := new(mocks.CompanyRepository)
+	company := &domain.Company{ID: 7, BIN: "080640000124", Name: "ACME LLP"}
+	members := []domain.CompanyMember{{CompanyID: 7, UserID: 1, Username: "aigerim", Role: domain.CompanyOwner}}
+	u := ucase.NewCompanyUsecase(mockUserRepo, mockCompanyRepo, 2*time.Second)
+
+	t.Run("success", func(t *testing.T) {
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(1)).Return(&members[0], nil).Once()
+		mockCompanyRepo.On("GetCompanyByID", mock.Anything, int64(7)).Return(company, nil).Once()
+		mockCompanyRepo.On("ListCompanyMembers", mock.Anything, int64(7)).Return(members, nil).Once()
+
+		profile, err := u.GetCompany(context.Background(), domain.User{ID: 1}, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, *company, profile.Company)
+		assert.True(t, profile.Can(domain.PermCompanyMembers))
+
+		mockCompanyRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		// companies of others are not found
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(2)).Return(nil, domain.ErrNotFound).Once()
+		_, err := u.GetCompany(context.Background(), domain.User{ID: 2}, 7)
+		assert.Equal(t, domain.KindNotFound, domain.KindOf(err))
+
+		mockCompanyRepo.AssertExpectations(t)
+	})
+}
+
+func TestSetCompanyMember(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockCompanyRepo := new(mocks.CompanyRepository)
+	owner := &domain.CompanyMember{CompanyID: 7, UserID: 1, Role: domain.CompanyOwner}
+	manager := &domain.CompanyMember{CompanyID: 7, UserID: 2, Role: domain.CompanyManager}
+	clerk := &domain.User{ID: 3, Username: "clerk"}
+	u := ucase.NewCompanyUsecase(mockUserRepo, mockCompanyRepo, 2*time.Second)
+
+	t.Run("success", func(t *testing.T) {
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(2)).Return(manager, nil).Once()
+		mockUserRepo.On("GetUserByUsername", mock.Anything, "clerk").Return(clerk, nil).Once()
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(3)).Return(nil, domain.ErrNotFound).Once()
+		mockCompanyRepo.On("SetCompanyMember", mock.Anything, &domain.CompanyMember{CompanyID: 7, UserID: 3, Username: "clerk", Role: domain.CompanyViewer}).Return(nil).Once()
+
+		member, err := u.SetCompanyMember(context.Background(), domain.User{ID: 2}, 7, "clerk", domain.CompanyViewer)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), member.UserID)
+
+		mockUserRepo.AssertExpectations(t)
+		mockCompanyRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		_, err := u.SetCompanyMember(context.Background(), domain.User{ID: 2}, 7, "clerk", "director")
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+
+		// only owners hand out the owner role
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(2)).Return(manager, nil).Once()
+		mockUserRepo.On("GetUserByUsername", mock.Anything, "clerk").Return(clerk, nil).Once()
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(3)).Return(nil, domain.ErrNotFound).Once()
+		_, err = u.SetCompanyMember(context.Background(), domain.User{ID: 2}, 7, "clerk", domain.CompanyOwner)
+		assert.Equal(t, domain.KindForbidden, domain.KindOf(err))
+
+		// viewers cannot manage members
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(3)).Return(&domain.CompanyMember{Role: domain.CompanyViewer}, nil).Once()
+		_, err = u.SetCompanyMember(context.Background(), domain.User{ID: 3}, 7, "clerk", domain.CompanyManager)
+		assert.Equal(t, domain.KindForbidden, domain.KindOf(err))
+
+		// the last owner cannot step down
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(1)).Return(owner, nil).Twice()
+		mockUserRepo.On("GetUserByUsername", mock.Anything, "aigerim").Return(&domain.User{ID: 1, Username: "aigerim"}, nil).Once()
+		mockCompanyRepo.On("CountCompanyOwners", mock.Anything, int64(7)).Return(int64(1), nil).Once()
+		_, err = u.SetCompanyMember(context.Background(), domain.User{ID: 1}, 7, "aigerim", domain.CompanyViewer)
+		assert.Equal(t, domain.KindConflict, domain.KindOf(err))
+
+		mockUserRepo.AssertExpectations(t)
+		mockCompanyRepo.AssertExpectations(t)
+	})
+}
+
+func TestRemoveCompanyMember(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockCompanyRepo := new(mocks.CompanyRepository)
+	owner := &domain.CompanyMember{CompanyID: 7, UserID: 1, Role: domain.CompanyOwner}
+	viewer := &domain.CompanyMember{CompanyID: 7, UserID: 3, Role: domain.CompanyViewer}
+	u := ucase.NewCompanyUsecase(mockUserRepo, mockCompanyRepo, 2*time.Second)
+
+	t.Run("success", func(t *testing.T) {
+		// a viewer leaves the company
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(3)).Return(viewer, nil).Twice()
+		mockCompanyRepo.On("RemoveCompanyMember", mock.Anything, int64(7), int64(3)).Return(nil).Once()
+
+		assert.NoError(t, u.RemoveCompanyMember(context.Background(), domain.User{ID: 3}, 7, 3))
+		mockCompanyRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(3)).Return(viewer, nil).Once()
+		err := u.RemoveCompanyMember(context.Background(), domain.User{ID: 3}, 7, 1)
+		assert.Equal(t, domain.KindForbidden, domain.KindOf(err))
+
+		mockCompanyRepo.On("GetCompanyMember", mock.Anything, int64(7), int64(1)).Return(owner, nil).Twice()
+		mockCompanyRepo.On("CountCompanyOwners", mock.Anything, int64(7)).Return(int64(1), nil).Once()
+		err = u.RemoveCompanyMember(context.Background(), domain.User{ID: 1}, 7, 1)
+		assert.Equal(t, domain.KindConflict, domain.KindOf(err))
+
+		mockCompanyRepo.AssertExpectations(t)
+	})
+}
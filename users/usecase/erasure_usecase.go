@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transaction-service/domain"
+	"transaction-service/logging"
+)
+
+type erasureUsecase struct {
+	userRepo domain.UserRepository
+	jwt      domain.JwtTokenUsecase
+	// gracePeriod is how long a deleted account is kept before the erasure.
+	gracePeriod    time.Duration
+	batchSize      int
+	timeoutContext time.Duration
+}
+
+func NewErasureUsecase(userRepo domain.UserRepository, jwt domain.JwtTokenUsecase, gracePeriod time.Duration, batchSize int, time time.Duration) domain.ErasureUsecase {
+	return &erasureUsecase{userRepo: userRepo, jwt: jwt, gracePeriod: gracePeriod, batchSize: batchSize, timeoutContext: time}
+}
+
+// EraseDeleted erases at most one batch of accounts. Sessions are purged first
+// so an erased account never keeps a valid token, a failed account is retried
+// at the next run.
+func (e *erasureUsecase) EraseDeleted(ctx context.Context, now time.Time) ([]int64, error) {
+	context, cancel := context.WithTimeout(ctx, e.timeoutContext)
+	ids, err := e.userRepo.ListErasableRepo(context, now.Add(-e.gracePeriod), e.batchSize)
+	cancel()
+	if err != nil {
+		return nil, domain.Internal("cannot list deleted accounts", err)
+	}
+
+	erased := []int64{}
+	var failed error
+	for _, id := range ids {
+		if err := e.erase(ctx, id); err != nil {
+			logging.Ctx(ctx).Err(err).Int64("user", id).Msg("erasure failed")
+			failed = err
+			continue
+		}
+		erased = append(erased, id)
+	}
+	if failed != nil {
+		return erased, domain.Internal(fmt.Sprintf("cannot erase %d of %d accounts", len(ids)-len(erased), len(ids)), failed)
+	}
+	return erased, nil
+}
+
+func (e *erasureUsecase) erase(ctx context.Context, id int64) error {
+	context, cancel := context.WithTimeout(ctx, e.timeoutContext)
+	defer cancel()
+
+	if err := e.jwt.RevokeToken(context, id); err != nil {
+		return err
+	}
+	if err := e.jwt.DeleteImpersonationToken(context, id); err != nil {
+		return err
+	}
+	return e.userRepo.EraseUserRepo(context, id)
+}
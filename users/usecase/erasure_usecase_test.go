@@ -0,0 +1,58 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	ucase "transaction-service/users/usecase"
+)
+
+func TestEraseDeleted(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	graceOver := now.Add(-30 * 24 * time.Hour)
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockJwt := new(mocks.JwtTokenUsecase)
+		mockUserRepo.On("ListErasableRepo", mock.Anything, graceOver, 100).Return([]int64{3, 4}, nil).Once()
+		for _, id := range []int64{3, 4} {
+			mockJwt.On("RevokeToken", mock.Anything, id).Return(nil).Once()
+			mockJwt.On("DeleteImpersonationToken", mock.Anything, id).Return(nil).Once()
+			mockUserRepo.On("EraseUserRepo", mock.Anything, id).Return(nil).Once()
+		}
+
+		u := ucase.NewErasureUsecase(mockUserRepo, mockJwt, 30*24*time.Hour, 100, 2*time.Second)
+		erased, err := u.EraseDeleted(context.Background(), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{3, 4}, erased)
+		mockUserRepo.AssertExpectations(t)
+		mockJwt.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockJwt := new(mocks.JwtTokenUsecase)
+		mockUserRepo.On("ListErasableRepo", mock.Anything, graceOver, 100).Return([]int64{3, 4}, nil).Once()
+		// the sessions of 3 cannot be purged so its data is kept for the next run
+		mockJwt.On("RevokeToken", mock.Anything, int64(3)).Return(errors.New("redis down")).Once()
+		mockJwt.On("RevokeToken", mock.Anything, int64(4)).Return(nil).Once()
+		mockJwt.On("DeleteImpersonationToken", mock.Anything, int64(4)).Return(nil).Once()
+		mockUserRepo.On("EraseUserRepo", mock.Anything, int64(4)).Return(nil).Once()
+
+		u := ucase.NewErasureUsecase(mockUserRepo, mockJwt, 30*24*time.Hour, 100, 2*time.Second)
+		erased, err := u.EraseDeleted(context.Background(), now)
+
+		assert.Equal(t, domain.KindInternal, domain.KindOf(err))
+		assert.Equal(t, []int64{4}, erased)
+		mockUserRepo.AssertNotCalled(t, "EraseUserRepo", mock.Anything, int64(3))
+		mockUserRepo.AssertExpectations(t)
+		mockJwt.AssertExpectations(t)
+	})
+}
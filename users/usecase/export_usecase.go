@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+	"transaction-service/domain"
+)
+
+// exportPageSize is how many audit events are read at once.
+const exportPageSize = 500
+
+type exportUsecase struct {
+	userRepo       domain.UserRepository
+	companyRepo    domain.CompanyRepository
+	auditRepo      domain.AuditRepository
+	jwt            domain.JwtTokenUsecase
+	timeoutContext time.Duration
+}
+
+func NewExportUsecase(userRepo domain.UserRepository, companyRepo domain.CompanyRepository, auditRepo domain.AuditRepository, jwt domain.JwtTokenUsecase, time time.Duration) domain.ExportUsecase {
+	return &exportUsecase{userRepo: userRepo, companyRepo: companyRepo, auditRepo: auditRepo, jwt: jwt, timeoutContext: time}
+}
+
+func (x *exportUsecase) ExportUserData(ctx context.Context, id int64) (*domain.DataExport, error) {
+	context, cancel := context.WithTimeout(ctx, x.timeoutContext)
+	defer cancel()
+
+	user, err := x.userRepo.GetUserByID(context, id)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	changedAt, err := x.userRepo.GetPasswordChangedAtRepo(context, id)
+	if err != nil {
+		return nil, domain.Internal("cannot load password age", err)
+	}
+	export := &domain.DataExport{
+		GeneratedAt: time.Now().UTC(),
+		Profile: domain.ExportProfile{
+			ID:                user.ID,
+			IIN:               user.IIN,
+			Username:          user.Username,
+			Email:             user.Email,
+			Role:              user.Role,
+			AccountType:       user.AccountType,
+			Status:            user.Status,
+			RegisterDate:      user.RegisterDate,
+			PasswordChangedAt: changedAt,
+			DeletedAt:         user.DeletedAt,
+		},
+		Accounts: []domain.Accounts{},
+	}
+
+	if export.Companies, err = x.companyRepo.ListUserCompanies(context, id); err != nil {
+		return nil, domain.Internal("cannot load companies", err)
+	}
+	if export.StatusHistory, err = x.userRepo.ListStatusHistoryRepo(context, id); err != nil {
+		return nil, domain.Internal("cannot load status history", err)
+	}
+	if export.Sessions, err = x.sessions(context, id); err != nil {
+		return nil, err
+	}
+	if export.LoginHistory, export.AuditEvents, err = x.events(context, id); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// sessions returns the sessions of the user and the impersonation sessions
+// the user started.
+func (x *exportUsecase) sessions(ctx context.Context, id int64) ([]domain.Session, error) {
+	all, err := x.jwt.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []domain.Session{}
+	for _, s := range all {
+		if s.UserID == id || s.ActorID == id {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+// events splits the audit events of the user into the sign ins and the rest.
+// The address and browser of other actors, e.g. staff viewing the profile,
+// are not the personal data of the user and are left out.
+func (x *exportUsecase) events(ctx context.Context, id int64) ([]domain.AuditEvent, []domain.AuditEvent, error) {
+	logins, others := []domain.AuditEvent{}, []domain.AuditEvent{}
+	filter := domain.AuditFilter{SubjectID: id, Limit: exportPageSize}
+	for {
+		events, total, err := x.auditRepo.ListEvents(ctx, filter)
+		if err != nil {
+			return nil, nil, domain.Internal("cannot load audit events", err)
+		}
+		for _, event := range events {
+			if event.ActorID != id {
+				event.IP, event.UserAgent = "", ""
+			}
+			if strings.HasPrefix(event.Action, "login.") {
+				logins = append(logins, event)
+			} else {
+				others = append(others, event)
+			}
+		}
+		filter.Offset += len(events)
+		if len(events) == 0 || int64(filter.Offset) >= total {
+			return logins, others, nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	ucase "transaction-service/users/usecase"
+)
+
+func TestExportUserData(t *testing.T) {
+	user := &domain.User{ID: 7, IIN: "940217450216", Username: "aigerim", Password: "hash", Role: "user", Status: domain.StatusActive}
+	changedAt := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockCompanyRepo := new(mocks.CompanyRepository)
+		mockAuditRepo := new(mocks.AuditRepository)
+		mockJwt := new(mocks.JwtTokenUsecase)
+
+		mockUserRepo.On("GetUserByID", mock.Anything, int64(7)).Return(user, nil).Once()
+		mockUserRepo.On("GetPasswordChangedAtRepo", mock.Anything, int64(7)).Return(changedAt, nil).Once()
+		mockUserRepo.On("ListStatusHistoryRepo", mock.Anything, int64(7)).Return([]domain.StatusChange{{UserID: 7, To: domain.StatusActive}}, nil).Once()
+		mockCompanyRepo.On("ListUserCompanies", mock.Anything, int64(7)).Return([]domain.Membership{}, nil).Once()
+		mockJwt.On("ListSessions", mock.Anything).Return([]domain.Session{{UserID: 7}, {UserID: 8}, {ActorID: 7}}, nil).Once()
+		mockAuditRepo.On("ListEvents", mock.Anything, domain.AuditFilter{SubjectID: 7, Limit: 500}).Return([]domain.AuditEvent{
+			{ID: 3, Action: domain.AuditUserDataView, ActorID: 1, TargetID: 7, IP: "10.0.0.1", UserAgent: "staff"},
+			{ID: 2, Action: domain.AuditLoginSuccess, ActorID: 7, TargetID: 7, IP: "10.0.0.7", UserAgent: "mine"},
+		}, int64(2), nil).Once()
+
+		u := ucase.NewExportUsecase(mockUserRepo, mockCompanyRepo, mockAuditRepo, mockJwt, 2*time.Second)
+		data, err := u.ExportUserData(context.Background(), 7)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "aigerim", data.Profile.Username)
+		assert.Equal(t, changedAt, data.Profile.PasswordChangedAt)
+		assert.Equal(t, []domain.Session{{UserID: 7}, {ActorID: 7}}, data.Sessions)
+		assert.Len(t, data.StatusHistory, 1)
+		if assert.Len(t, data.LoginHistory, 1) {
+			assert.Equal(t, "10.0.0.7", data.LoginHistory[0].IP)
+		}
+		if assert.Len(t, data.AuditEvents, 1) {
+			// the address of the staff member is not the data of the user
+			assert.Empty(t, data.AuditEvents[0].IP)
+			assert.Empty(t, data.AuditEvents[0].UserAgent)
+		}
+		mockUserRepo.AssertExpectations(t)
+		mockCompanyRepo.AssertExpectations(t)
+		mockAuditRepo.AssertExpectations(t)
+		mockJwt.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("GetUserByID", mock.Anything, int64(9)).Return(nil, domain.ErrNotFound).Once()
+
+		u := ucase.NewExportUsecase(mockUserRepo, new(mocks.CompanyRepository), new(mocks.AuditRepository), new(mocks.JwtTokenUsecase), 2*time.Second)
+		_, err := u.ExportUserData(context.Background(), 9)
+		assert.Equal(t, domain.KindNotFound, domain.KindOf(err))
+
+		mockUserRepo.On("GetUserByID", mock.Anything, int64(7)).Return(user, nil).Once()
+		mockUserRepo.On("GetPasswordChangedAtRepo", mock.Anything, int64(7)).Return(time.Time{}, errors.New("db down")).Once()
+		_, err = u.ExportUserData(context.Background(), 7)
+		assert.Equal(t, domain.KindInternal, domain.KindOf(err))
+		mockUserRepo.AssertExpectations(t)
+	})
+}
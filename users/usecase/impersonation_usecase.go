@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"transaction-service/domain"
+	"transaction-service/logging"
+)
+
+type impersonationUsecase struct {
+	userRepo       domain.UserRepository
+	impRepo        domain.ImpersonationRepository
+	timeoutContext time.Duration
+}
+
+func NewImpersonationUsecase(userRepo domain.UserRepository, impRepo domain.ImpersonationRepository, time time.Duration) domain.ImpersonationUsecase {
+	return &impersonationUsecase{userRepo: userRepo, impRepo: impRepo, timeoutContext: time}
+}
+
+func (i *impersonationUsecase) StartImpersonation(ctx context.Context, actor domain.User, targetID int64, reason, ip, userAgent string) (*domain.User, error) {
+	context, cancel := context.WithTimeout(ctx, i.timeoutContext)
+	defer cancel()
+
+	if actor.Actor != nil {
+		return nil, domain.Forbidden(domain.CodeImpersonation, "impersonation already in progress", fmt.Errorf("actor %d is impersonating user %d", actor.Actor.ID, actor.ID))
+	}
+	if !domain.HasPermission(actor.Role, domain.PermImpersonate) {
+		return nil, domain.Forbidden(domain.CodeAccessDenied, "access denied", fmt.Errorf("role %q cannot impersonate", actor.Role))
+	}
+	if actor.ID == targetID {
+		return nil, domain.Validation(domain.CodeImpersonation, "cannot impersonate yourself", fmt.Errorf("actor %d targets itself", actor.ID))
+	}
+
+	target, err := i.userRepo.GetUserByID(context, targetID)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if domain.HasPermission(target.Role, domain.PermImpersonate) {
+		return nil, domain.Forbidden(domain.CodeImpersonation, "staff accounts cannot be impersonated", fmt.Errorf("target %d has role %q", target.ID, target.Role))
+	}
+
+	imp := &domain.Impersonation{
+		ActorID:   actor.ID,
+		TargetID:  target.ID,
+		Reason:    reason,
+		IP:        ip,
+		UserAgent: userAgent,
+		StartedAt: time.Now(),
+	}
+	if err := i.impRepo.CreateImpersonation(context, imp); err != nil {
+		return nil, domain.Internal("cannot start impersonation", err)
+	}
+	logging.Ctx(ctx).Info().Int64("actor", actor.ID).Int64("target", target.ID).Str("ip", ip).Msg("impersonation started")
+	return target, nil
+}
+
+func (i *impersonationUsecase) StopImpersonation(ctx context.Context, actorID int64) error {
+	context, cancel := context.WithTimeout(ctx, i.timeoutContext)
+	defer cancel()
+
+	if err := i.impRepo.EndImpersonation(context, actorID, time.Now()); err != nil {
+		return domain.Internal("cannot stop impersonation", err)
+	}
+	logging.Ctx(ctx).Info().Int64("actor", actorID).Msg("impersonation stopped")
+	return nil
+}
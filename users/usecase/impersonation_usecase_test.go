@@ -0,0 +1,60 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	ucase "transaction-service/users/usecase"
+)
+
+func TestStartImpersonation(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	mockImpRepo := new(mocks.ImpersonationRepository)
+	actor := domain.User{ID: 1, Role: "support"}
+	target := &domain.User{ID: 25, Username: "content", Role: "user"}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetUserByID", mock.Anything, target.ID).Return(target, nil).Once()
+		mockImpRepo.On("CreateImpersonation", mock.Anything, mock.AnythingOfType("*domain.Impersonation")).Return(nil).Once()
+
+		u := ucase.NewImpersonationUsecase(mockUserRepo, mockImpRepo, 2*time.Second)
+		a, err := u.StartImpersonation(context.Background(), actor, target.ID, "ticket 42", "127.0.0.1", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, target, a)
+
+		mockUserRepo.AssertExpectations(t)
+		mockImpRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		u := ucase.NewImpersonationUsecase(mockUserRepo, mockImpRepo, 2*time.Second)
+
+		_, err := u.StartImpersonation(context.Background(), domain.User{ID: 2, Role: "user"}, target.ID, "", "", "")
+		assert.Error(t, err)
+		assert.Equal(t, domain.KindForbidden, domain.KindOf(err))
+
+		nested := actor
+		nested.Actor = &domain.Actor{ID: 3, Role: "admin"}
+		_, err = u.StartImpersonation(context.Background(), nested, target.ID, "", "", "")
+		assert.Error(t, err)
+
+		mockUserRepo.On("GetUserByID", mock.Anything, int64(3)).Return(&domain.User{ID: 3, Role: "admin"}, nil).Once()
+		_, err = u.StartImpersonation(context.Background(), actor, 3, "", "", "")
+		assert.Error(t, err)
+		assert.Equal(t, domain.KindForbidden, domain.KindOf(err))
+
+		mockUserRepo.On("GetUserByID", mock.Anything, int64(4)).Return(nil, domain.ErrNotFound).Once()
+		_, err = u.StartImpersonation(context.Background(), actor, 4, "", "", "")
+		assert.Error(t, err)
+		assert.Equal(t, domain.KindNotFound, domain.KindOf(err))
+
+		mockUserRepo.AssertExpectations(t)
+		mockImpRepo.AssertExpectations(t)
+	})
+}
@@ -1,8 +1,14 @@
 package usecase
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 	"transaction-service/domain"
 
@@ -14,10 +20,15 @@ import (
 type jwtUsecase struct {
 	token domain.JwtToken
 	redis domain.JwtTokenRepo
+	keys  *keyring
 }
 
-func NewJWTUseCase(token domain.JwtToken, redis domain.JwtTokenRepo) domain.JwtTokenUsecase {
-	return &jwtUsecase{token: token, redis: redis}
+// NewJWTUseCase signs tokens with the configured secret until ReloadSigningKeys
+// finds rotated keys in the repository.
+func NewJWTUseCase(token domain.JwtToken, redis domain.JwtTokenRepo, keys domain.SigningKeyRepository) domain.JwtTokenUsecase {
+	j := &jwtUsecase{token: token, redis: redis}
+	j.keys = &keyring{repo: keys, fallback: []byte(token.AccessSecret), retention: j.maxTTL()}
+	return j
 }
 
 func (j *jwtUsecase) GenerateToken(id int64, role, iin string) (string, error) {
@@ -28,10 +39,9 @@ func (j *jwtUsecase) GenerateToken(id int64, role, iin string) (string, error) {
 	accessTokenClaims["iin"] = iin
 	accessTokenClaims["iat"] = time.Now().Unix()
 	accessTokenClaims["exp"] = time.Now().Add(j.token.AccessTtl).Unix()
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
-	signedToken, err := accessToken.SignedString([]byte(j.token.AccessSecret))
+	signedToken, err := j.sign(accessTokenClaims)
 	if err != nil {
-		return "", &domain.LogError{"cannot create signed token", err, http.StatusInternalServerError}
+		return "", domain.Internal("cannot create signed token", err)
 	}
 	return signedToken, nil
 }
@@ -39,11 +49,11 @@ func (j *jwtUsecase) GenerateToken(id int64, role, iin string) (string, error) {
 func (j *jwtUsecase) ParseTokenAndGetID(token string) (int64, error) {
 	claims, err := j.ParseToken(token)
 	if err != nil {
-		return -1, &domain.LogError{"invalid token", err, http.StatusBadRequest}
+		return -1, domain.Unauthorized(domain.CodeInvalidToken, "invalid token", err)
 	}
 	id, ok := claims["id"].(float64)
 	if !ok {
-		return -1, &domain.LogError{"invalid token", fmt.Errorf("id not found from token"), http.StatusBadRequest}
+		return -1, domain.Unauthorized(domain.CodeInvalidToken, "invalid token", fmt.Errorf("id not found from token"))
 	}
 	return int64(id), nil
 }
@@ -51,44 +61,346 @@ func (j *jwtUsecase) ParseTokenAndGetID(token string) (int64, error) {
 func (j *jwtUsecase) ParseTokenAndGetRole(token string) (string, error) {
 	claims, err := j.ParseToken(token)
 	if err != nil {
-		return "", &domain.LogError{"invalid token", err, http.StatusBadRequest}
+		return "", domain.Unauthorized(domain.CodeInvalidToken, "invalid token", err)
 	}
 	role, ok := claims["role"].(string)
 	if !ok {
-		return "", &domain.LogError{"invalid token", fmt.Errorf("role not found from token"), http.StatusBadRequest}
+		return "", domain.Unauthorized(domain.CodeInvalidToken, "invalid token", fmt.Errorf("role not found from token"))
 	}
 	return role, nil
 }
 
-func (j *jwtUsecase) InsertToken(id int64, token string) error {
+func (j *jwtUsecase) GenerateImpersonationToken(target *domain.User, actor domain.Actor) (string, error) {
+	accessTokenClaims := jwt.MapClaims{}
+
+	accessTokenClaims["id"] = target.ID
+	accessTokenClaims["role"] = target.Role
+	accessTokenClaims["iin"] = target.IIN
+	accessTokenClaims["act"] = map[string]interface{}{"sub": strconv.FormatInt(actor.ID, 10), "role": actor.Role}
+	accessTokenClaims["iat"] = time.Now().Unix()
+	accessTokenClaims["exp"] = time.Now().Add(j.impersonationTTL()).Unix()
+	signedToken, err := j.sign(accessTokenClaims)
+	if err != nil {
+		return "", domain.Internal("cannot create signed token", err)
+	}
+	return signedToken, nil
+}
+
+// ParseTokenAndGetActor returns the impersonating actor, or nil for a regular session token.
+func (j *jwtUsecase) ParseTokenAndGetActor(token string) (*domain.Actor, error) {
+	claims, err := j.ParseToken(token)
+	if err != nil {
+		return nil, domain.Unauthorized(domain.CodeInvalidToken, "invalid token", err)
+	}
+	act, ok := claims["act"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	sub, _ := act["sub"].(string)
+	id, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil {
+		return nil, domain.Unauthorized(domain.CodeInvalidToken, "invalid token", fmt.Errorf("actor not found from token"))
+	}
+	role, _ := act["role"].(string)
+	return &domain.Actor{ID: id, Role: role}, nil
+}
+
+func (j *jwtUsecase) InsertImpersonationToken(ctx context.Context, actorID int64, token string) error {
+	key := fmt.Sprintf("impersonation:%d", actorID)
+	if err := j.redis.InsertTokenRepo(ctx, key, token, j.impersonationTTL()); err != nil {
+		return domain.Internal("cannot insert token", err)
+	}
+	return nil
+}
+
+func (j *jwtUsecase) FindImpersonationToken(ctx context.Context, actorID int64, token string) (bool, error) {
+	key := fmt.Sprintf("impersonation:%d", actorID)
+
+	ok, err := j.redis.FindTokenRepo(ctx, key, token)
+	if err != nil {
+		return false, domain.Unauthorized(domain.CodeSessionNotFound, "session not found", err)
+	}
+	return ok, nil
+}
+
+func (j *jwtUsecase) DeleteImpersonationToken(ctx context.Context, actorID int64) error {
+	key := fmt.Sprintf("impersonation:%d", actorID)
+	if err := j.redis.DeleteTokenRepo(ctx, key); err != nil {
+		return domain.Internal("cannot delete token", err)
+	}
+	return nil
+}
+
+func (j *jwtUsecase) RevokeToken(ctx context.Context, id int64) error {
+	for _, key := range []string{fmt.Sprintf("user:%d", id), fmt.Sprintf("impersonation:%d", id)} {
+		if err := j.redis.DeleteTokenRepo(ctx, key); err != nil {
+			return domain.Internal("cannot revoke token", err)
+		}
+	}
+	return nil
+}
+
+func (j *jwtUsecase) ListSessions(ctx context.Context) ([]domain.Session, error) {
+	sessions := []domain.Session{}
+	for _, prefix := range []string{"user:", "impersonation:"} {
+		tokens, err := j.redis.ListTokensRepo(ctx, prefix+"*")
+		if err != nil {
+			return nil, domain.Internal("cannot list sessions", err)
+		}
+		for key, ttl := range tokens {
+			id, err := strconv.ParseInt(strings.TrimPrefix(key, prefix), 10, 64)
+			if err != nil {
+				continue
+			}
+			session := domain.Session{UserID: id, TTL: ttl}
+			if prefix == "impersonation:" {
+				session = domain.Session{ActorID: id, TTL: ttl}
+			}
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (j *jwtUsecase) ReloadSigningKeys(ctx context.Context) error {
+	if err := j.keys.reload(ctx); err != nil {
+		return domain.Internal("cannot load signing keys", err)
+	}
+	return nil
+}
+
+// RotateSigningKey creates a new active key and retires the previous ones. Retired
+// keys keep verifying tokens until the longest token lifetime has passed.
+func (j *jwtUsecase) RotateSigningKey(ctx context.Context) (*domain.SigningKey, error) {
+	now := time.Now()
+	existing, err := j.keys.repo.ListKeys(ctx, now.Add(-j.keys.retention))
+	if err != nil {
+		return nil, domain.Internal("cannot load signing keys", err)
+	}
+	if len(existing) == 0 {
+		// keep sessions signed with the configured secret valid until they expire
+		legacy := &domain.SigningKey{ID: legacyKeyID, Secret: []byte(j.token.AccessSecret), CreatedAt: now.Add(-time.Second)}
+		if err := j.keys.repo.CreateKey(ctx, legacy); err != nil {
+			return nil, domain.Internal("cannot store legacy signing key", err)
+		}
+	}
+
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, domain.Internal("cannot generate signing key", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, domain.Internal("cannot generate signing key", err)
+	}
+	key := &domain.SigningKey{ID: hex.EncodeToString(id), Secret: secret, CreatedAt: now}
+	if err := j.keys.repo.CreateKey(ctx, key); err != nil {
+		return nil, domain.Internal("cannot store signing key", err)
+	}
+	if err := j.keys.repo.RetireKeys(ctx, key.ID, now); err != nil {
+		return nil, domain.Internal("cannot retire signing keys", err)
+	}
+	if err := j.keys.repo.DeleteRetiredKeys(ctx, now.Add(-j.keys.retention)); err != nil {
+		return nil, domain.Internal("cannot purge signing keys", err)
+	}
+	return key, j.ReloadSigningKeys(ctx)
+}
+
+func (j *jwtUsecase) sign(claims jwt.MapClaims) (string, error) {
+	kid, secret := j.keys.signing()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(secret)
+}
+
+// maxTTL is the longest lifetime of any token this usecase issues.
+func (j *jwtUsecase) maxTTL() time.Duration {
+	ttl := j.token.AccessTtl
+	for _, d := range []time.Duration{j.token.ImpersonationTtl, j.token.ExchangeTtl} {
+		if d > ttl {
+			ttl = d
+		}
+	}
+	return ttl
+}
+
+func (j *jwtUsecase) InsertToken(ctx context.Context, id int64, token string) error {
 
 	key := fmt.Sprintf("user:%d", id)
-	if err := j.redis.InsertTokenRepo(key, token, j.GetAccessTTL()); err != nil {
-		return &domain.LogError{"cannot insert token", err, http.StatusInternalServerError}
+	if err := j.redis.InsertTokenRepo(ctx, key, token, j.GetAccessTTL()); err != nil {
+		return domain.Internal("cannot insert token", err)
 	}
 	return nil
 }
 
-func (j *jwtUsecase) FindToken(id int64, token string) (bool, error) {
+func (j *jwtUsecase) FindToken(ctx context.Context, id int64, token string) (bool, error) {
 	key := fmt.Sprintf("user:%d", id)
 
-	ok, err := j.redis.FindTokenRepo(key, token)
+	ok, err := j.redis.FindTokenRepo(ctx, key, token)
 	if err != nil {
-		return false, &domain.LogError{"cannot find token", err, http.StatusBadRequest}
+		return false, domain.Unauthorized(domain.CodeSessionNotFound, "session not found", err)
 	}
 	return ok, nil
 }
 
+// ExchangeToken implements the RFC 8693 token exchange grant. Exchanged tokens
+// are not stored in redis, so they cannot be used as a session on this service.
+func (j *jwtUsecase) ExchangeToken(ctx context.Context, req *domain.TokenExchangeRequest) (*domain.TokenExchangeResponse, error) {
+	secret, ok := j.token.ExchangeClients[req.ClientID]
+	if !ok || req.ClientSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(req.ClientSecret)) != 1 {
+		return nil, domain.Unauthorized(domain.ErrInvalidClient, "client authentication failed", fmt.Errorf("client %q", req.ClientID))
+	}
+	if req.GrantType != domain.GrantTypeTokenExchange {
+		return nil, domain.Validation(domain.ErrUnsupportedGrantType, fmt.Sprintf("grant type %q is not supported", req.GrantType), nil)
+	}
+	if req.SubjectToken == "" || !isJWTTokenType(req.SubjectTokenType) {
+		return nil, domain.Validation(domain.ErrInvalidRequest, "subject_token and a supported subject_token_type are required", nil)
+	}
+	if req.RequestedTokenType != "" && !isJWTTokenType(req.RequestedTokenType) {
+		return nil, domain.Validation(domain.ErrInvalidRequest, fmt.Sprintf("requested_token_type %q is not supported", req.RequestedTokenType), nil)
+	}
+
+	subject, id, err := j.activeClaims(ctx, req.SubjectToken)
+	if err != nil {
+		return nil, domain.Validation(domain.ErrInvalidGrant, "invalid subject token", err)
+	}
+
+	audience, err := exchangeAudience(req.ClientID, req.Audience)
+	if err != nil {
+		return nil, domain.Validation(domain.ErrInvalidTarget, err.Error(), nil)
+	}
+	scope, err := j.exchangeScope(req.Scope)
+	if err != nil {
+		return nil, domain.Validation(domain.ErrInvalidScope, err.Error(), nil)
+	}
+
+	ttl := j.token.ExchangeTtl
+	if ttl <= 0 {
+		ttl = j.token.AccessTtl
+	}
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	if exp, ok := subject["exp"].(float64); ok && time.Unix(int64(exp), 0).Before(expiresAt) {
+		expiresAt = time.Unix(int64(exp), 0)
+	}
+
+	claims := jwt.MapClaims{}
+	claims["id"] = id
+	claims["sub"] = strconv.FormatInt(id, 10)
+	claims["role"] = subject["role"]
+	if contains(scope, domain.ScopeIINRead) {
+		claims["iin"] = subject["iin"]
+	}
+	claims["aud"] = audience
+	claims["scope"] = strings.Join(scope, " ")
+	claims["iat"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+
+	if req.ActorToken != "" {
+		if !isJWTTokenType(req.ActorTokenType) {
+			return nil, domain.Validation(domain.ErrInvalidRequest, fmt.Sprintf("actor_token_type %q is not supported", req.ActorTokenType), nil)
+		}
+		actor, actorID, err := j.activeClaims(ctx, req.ActorToken)
+		if err != nil {
+			return nil, domain.Validation(domain.ErrInvalidGrant, "invalid actor token", err)
+		}
+		act := map[string]interface{}{"sub": strconv.FormatInt(actorID, 10)}
+		// keep the delegation chain when the actor is itself acting for someone
+		if prior, ok := actor["act"]; ok {
+			act["act"] = prior
+		}
+		claims["act"] = act
+	}
+
+	signedToken, err := j.sign(claims)
+	if err != nil {
+		return nil, domain.Internal("cannot create signed token", err)
+	}
+
+	return &domain.TokenExchangeResponse{
+		AccessToken:     signedToken,
+		IssuedTokenType: domain.TokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(time.Until(expiresAt).Seconds()),
+		Scope:           strings.Join(scope, " "),
+	}, nil
+}
+
+// activeClaims parses the token and checks that it is the current session of its owner.
+func (j *jwtUsecase) activeClaims(ctx context.Context, token string) (jwt.MapClaims, int64, error) {
+	claims, err := j.ParseToken(token)
+	if err != nil {
+		return nil, -1, err
+	}
+	id, ok := claims["id"].(float64)
+	if !ok {
+		return nil, -1, fmt.Errorf("id not found from token")
+	}
+	active, err := j.FindToken(ctx, int64(id), token)
+	if err != nil || !active {
+		return nil, -1, fmt.Errorf("token is not an active session")
+	}
+	return claims, int64(id), nil
+}
+
+// exchangeAudience binds the token to the client that asked for it, a
+// requested audience may only name the client itself.
+func exchangeAudience(clientID string, requested []string) (string, error) {
+	for _, aud := range requested {
+		if aud != clientID {
+			return "", fmt.Errorf("audience %q is not allowed for client %q", aud, clientID)
+		}
+	}
+	return clientID, nil
+}
+
+// exchangeScope checks the requested scopes. They must be named, a missing
+// scope would otherwise grant every exchangeable scope.
+func (j *jwtUsecase) exchangeScope(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("scope is required")
+	}
+	for _, scope := range requested {
+		if !contains(j.token.ExchangeScopes, scope) {
+			return nil, fmt.Errorf("scope %q is not allowed", scope)
+		}
+	}
+	return requested, nil
+}
+
+func isJWTTokenType(tokenType string) bool {
+	return tokenType == domain.TokenTypeAccessToken || tokenType == domain.TokenTypeJWT
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func (j *jwtUsecase) GetAccessTTL() time.Duration {
 	return j.token.AccessTtl
 }
 
+func (j *jwtUsecase) impersonationTTL() time.Duration {
+	if j.token.ImpersonationTtl <= 0 {
+		return j.token.AccessTtl
+	}
+	return j.token.ImpersonationTtl
+}
+
 func (j *jwtUsecase) ParseToken(token string) (jwt.MapClaims, error) {
 	JWTToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("failed to extract token metadata, unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(j.token.AccessSecret), nil
+		kid, _ := token.Header["kid"].(string)
+		return j.keys.verifying(kid)
 	})
 
 	if err != nil {
@@ -0,0 +1,215 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"transaction-service/domain"
+	"transaction-service/domain/mocks"
+	ucase "transaction-service/users/usecase"
+)
+
+func TestExchangeToken(t *testing.T) {
+	token := domain.JwtToken{
+		AccessSecret:    "secret",
+		AccessTtl:       30 * time.Minute,
+		ExchangeTtl:     5 * time.Minute,
+		ExchangeClients: map[string]string{"transaction-service": "client-secret-0123"},
+		ExchangeScopes:  []string{"accounts:read", "accounts:write", domain.ScopeIINRead},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRedis := new(mocks.JwtTokenRepo)
+		u := ucase.NewJWTUseCase(token, mockRedis, new(mocks.SigningKeyRepository))
+
+		subject, err := u.GenerateToken(7, "user", "940217450216")
+		assert.NoError(t, err)
+		actor, err := u.GenerateToken(1, "admin", "990824351277")
+		assert.NoError(t, err)
+		mockRedis.On("FindTokenRepo", mock.Anything, "user:7", subject).Return(true, nil).Once()
+		mockRedis.On("FindTokenRepo", mock.Anything, "user:1", actor).Return(true, nil).Once()
+
+		resp, err := u.ExchangeToken(context.Background(), &domain.TokenExchangeRequest{
+			ClientID:         "transaction-service",
+			ClientSecret:     "client-secret-0123",
+			GrantType:        domain.GrantTypeTokenExchange,
+			SubjectToken:     subject,
+			SubjectTokenType: domain.TokenTypeAccessToken,
+			ActorToken:       actor,
+			ActorTokenType:   domain.TokenTypeJWT,
+			Audience:         []string{"transaction-service"},
+			Scope:            []string{"accounts:read"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "accounts:read", resp.Scope)
+		assert.LessOrEqual(t, resp.ExpiresIn, int64(5*60))
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(resp.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte(token.AccessSecret), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "7", claims["sub"])
+		assert.Equal(t, "transaction-service", claims["aud"])
+		assert.Equal(t, map[string]interface{}{"sub": "1"}, claims["act"])
+		// the IIN is only handed out with the scope asking for it
+		assert.NotContains(t, claims, "iin")
+
+		mockRedis.AssertExpectations(t)
+	})
+	t.Run("iin-scope", func(t *testing.T) {
+		mockRedis := new(mocks.JwtTokenRepo)
+		u := ucase.NewJWTUseCase(token, mockRedis, new(mocks.SigningKeyRepository))
+
+		subject, err := u.GenerateToken(7, "user", "940217450216")
+		assert.NoError(t, err)
+		mockRedis.On("FindTokenRepo", mock.Anything, "user:7", subject).Return(true, nil).Once()
+
+		// the audience defaults to the authenticated client
+		resp, err := u.ExchangeToken(context.Background(), &domain.TokenExchangeRequest{
+			ClientID:         "transaction-service",
+			ClientSecret:     "client-secret-0123",
+			GrantType:        domain.GrantTypeTokenExchange,
+			SubjectToken:     subject,
+			SubjectTokenType: domain.TokenTypeJWT,
+			Scope:            []string{"accounts:read", domain.ScopeIINRead},
+		})
+		assert.NoError(t, err)
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(resp.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+			return []byte(token.AccessSecret), nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "940217450216", claims["iin"])
+		assert.Equal(t, "transaction-service", claims["aud"])
+
+		mockRedis.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockRedis := new(mocks.JwtTokenRepo)
+		u := ucase.NewJWTUseCase(token, mockRedis, new(mocks.SigningKeyRepository))
+
+		subject, err := u.GenerateToken(7, "user", "940217450216")
+		assert.NoError(t, err)
+		mockRedis.On("FindTokenRepo", mock.Anything, "user:7", subject).Return(true, nil)
+
+		id, secret := "transaction-service", "client-secret-0123"
+		cases := []struct {
+			req  domain.TokenExchangeRequest
+			code string
+		}{
+			{domain.TokenExchangeRequest{GrantType: domain.GrantTypeTokenExchange, SubjectToken: subject, SubjectTokenType: domain.TokenTypeJWT, Scope: []string{"accounts:read"}}, domain.ErrInvalidClient},
+			{domain.TokenExchangeRequest{ClientID: id, ClientSecret: "wrong", GrantType: domain.GrantTypeTokenExchange}, domain.ErrInvalidClient},
+			{domain.TokenExchangeRequest{ClientID: "unknown", ClientSecret: secret, GrantType: domain.GrantTypeTokenExchange}, domain.ErrInvalidClient},
+			{domain.TokenExchangeRequest{ClientID: id, ClientSecret: secret, GrantType: "password"}, domain.ErrUnsupportedGrantType},
+			{domain.TokenExchangeRequest{ClientID: id, ClientSecret: secret, GrantType: domain.GrantTypeTokenExchange, SubjectToken: subject}, domain.ErrInvalidRequest},
+			// a client cannot obtain tokens meant for another service
+			{domain.TokenExchangeRequest{ClientID: id, ClientSecret: secret, GrantType: domain.GrantTypeTokenExchange, SubjectToken: subject, SubjectTokenType: domain.TokenTypeJWT, Audience: []string{"unknown"}}, domain.ErrInvalidTarget},
+			{domain.TokenExchangeRequest{ClientID: id, ClientSecret: secret, GrantType: domain.GrantTypeTokenExchange, SubjectToken: subject, SubjectTokenType: domain.TokenTypeJWT, Audience: []string{"transaction-service"}, Scope: []string{"admin"}}, domain.ErrInvalidScope},
+			{domain.TokenExchangeRequest{ClientID: id, ClientSecret: secret, GrantType: domain.GrantTypeTokenExchange, SubjectToken: subject, SubjectTokenType: domain.TokenTypeJWT, Audience: []string{"transaction-service"}}, domain.ErrInvalidScope},
+		}
+		for _, c := range cases {
+			_, err := u.ExchangeToken(context.Background(), &c.req)
+			assert.Error(t, err)
+			assert.Equal(t, c.code, domain.CodeOf(err))
+		}
+	})
+	t.Run("revoked-subject", func(t *testing.T) {
+		mockRedis := new(mocks.JwtTokenRepo)
+		u := ucase.NewJWTUseCase(token, mockRedis, new(mocks.SigningKeyRepository))
+
+		subject, err := u.GenerateToken(7, "user", "940217450216")
+		assert.NoError(t, err)
+		mockRedis.On("FindTokenRepo", mock.Anything, "user:7", subject).Return(false, errors.New("redis: nil")).Once()
+
+		_, err = u.ExchangeToken(context.Background(), &domain.TokenExchangeRequest{
+			ClientID:         "transaction-service",
+			ClientSecret:     "client-secret-0123",
+			GrantType:        domain.GrantTypeTokenExchange,
+			SubjectToken:     subject,
+			SubjectTokenType: domain.TokenTypeJWT,
+			Audience:         []string{"transaction-service"},
+		})
+		assert.Error(t, err)
+		assert.Equal(t, domain.ErrInvalidGrant, domain.CodeOf(err))
+
+		mockRedis.AssertExpectations(t)
+	})
+}
+
+func TestRotateSigningKey(t *testing.T) {
+	token := domain.JwtToken{AccessSecret: "secret", AccessTtl: 30 * time.Minute}
+
+	t.Run("success", func(t *testing.T) {
+		mockRedis := new(mocks.JwtTokenRepo)
+		mockKeys := new(mocks.SigningKeyRepository)
+		u := ucase.NewJWTUseCase(token, mockRedis, mockKeys)
+
+		legacyToken, err := u.GenerateToken(7, "user", "940217450216")
+		assert.NoError(t, err)
+
+		var stored []domain.SigningKey
+		mockKeys.On("ListKeys", mock.Anything, mock.AnythingOfType("time.Time")).Return([]domain.SigningKey{}, nil).Once()
+		mockKeys.On("CreateKey", mock.Anything, mock.AnythingOfType("*domain.SigningKey")).Run(func(args mock.Arguments) {
+			stored = append(stored, *args.Get(1).(*domain.SigningKey))
+		}).Return(nil).Twice()
+		mockKeys.On("RetireKeys", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+			for i := range stored {
+				if stored[i].ID != args.String(1) {
+					retiredAt := args.Get(2).(time.Time)
+					stored[i].RetiredAt = &retiredAt
+				}
+			}
+		}).Return(nil).Once()
+		mockKeys.On("DeleteRetiredKeys", mock.Anything, mock.AnythingOfType("time.Time")).Return(nil).Once()
+		mockKeys.On("ListKeys", mock.Anything, mock.AnythingOfType("time.Time")).Return(func(context.Context, time.Time) []domain.SigningKey {
+			return stored
+		}, nil).Once()
+
+		key, err := u.RotateSigningKey(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, stored, 2)
+		assert.Equal(t, "legacy", stored[0].ID)
+		assert.Equal(t, key.ID, stored[1].ID)
+
+		rotatedToken, err := u.GenerateToken(7, "user", "940217450216")
+		assert.NoError(t, err)
+		parsed, _ := jwt.Parse(rotatedToken, nil)
+		assert.Equal(t, key.ID, parsed.Header["kid"])
+
+		// tokens signed before the rotation stay valid until the legacy key is purged
+		for _, signed := range []string{legacyToken, rotatedToken} {
+			id, err := u.ParseTokenAndGetID(signed)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(7), id)
+		}
+
+		mockKeys.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockKeys := new(mocks.SigningKeyRepository)
+		u := ucase.NewJWTUseCase(token, new(mocks.JwtTokenRepo), mockKeys)
+
+		mockKeys.On("ListKeys", mock.Anything, mock.AnythingOfType("time.Time")).Return([]domain.SigningKey{
+			{ID: "k1", Secret: []byte("rotated"), CreatedAt: time.Now()},
+		}, nil).Once()
+		assert.NoError(t, u.ReloadSigningKeys(context.Background()))
+
+		// the configured secret is no longer trusted once the legacy key is gone
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"id": 7, "exp": time.Now().Add(time.Minute).Unix(),
+		}).SignedString([]byte(token.AccessSecret))
+		assert.NoError(t, err)
+		_, err = u.ParseTokenAndGetID(forged)
+		assert.Error(t, err)
+
+		mockKeys.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"transaction-service/domain"
+)
+
+// legacyKeyID names the configured secret once it is stored next to rotated keys,
+// tokens without a kid header are verified with it until it is purged.
+const legacyKeyID = "legacy"
+
+// minReloadInterval limits reloads triggered by tokens with an unknown kid.
+const minReloadInterval = 5 * time.Second
+
+type keyring struct {
+	repo      domain.SigningKeyRepository
+	fallback  []byte
+	retention time.Duration
+
+	mu       sync.RWMutex
+	activeID string
+	keys     map[string][]byte
+	loadedAt time.Time
+}
+
+// signing returns the key new tokens are signed with, the kid is empty for the configured secret.
+func (k *keyring) signing() (string, []byte) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.activeID == "" {
+		return "", k.fallback
+	}
+	return k.activeID, k.keys[k.activeID]
+}
+
+func (k *keyring) verifying(kid string) ([]byte, error) {
+	k.mu.RLock()
+	secret, ok := k.lookup(kid)
+	stale := time.Since(k.loadedAt) > minReloadInterval
+	k.mu.RUnlock()
+	if ok {
+		return secret, nil
+	}
+
+	// the key may have been rotated by another instance
+	if kid != "" && stale && k.repo != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := k.reload(ctx); err != nil {
+			return nil, err
+		}
+		k.mu.RLock()
+		secret, ok = k.lookup(kid)
+		k.mu.RUnlock()
+		if ok {
+			return secret, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (k *keyring) lookup(kid string) ([]byte, bool) {
+	if kid == "" {
+		if len(k.keys) == 0 {
+			return k.fallback, true
+		}
+		kid = legacyKeyID
+	}
+	secret, ok := k.keys[kid]
+	return secret, ok
+}
+
+func (k *keyring) reload(ctx context.Context) error {
+	keys, err := k.repo.ListKeys(ctx, time.Now().Add(-k.retention))
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	activeID := ""
+	secrets := map[string][]byte{}
+	for _, key := range keys {
+		secrets[key.ID] = key.Secret
+		if key.RetiredAt == nil {
+			activeID = key.ID
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.activeID = activeID
+	k.keys = secrets
+	k.loadedAt = time.Now()
+	return nil
+}
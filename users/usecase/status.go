@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"transaction-service/domain"
+	"transaction-service/logging"
+	utils "transaction-service/utils"
+	"transaction-service/validation"
+)
+
+// statusTransitions is the lifecycle of an account, the statuses each status
+// can move to. A closed account stays closed.
+var statusTransitions = map[string][]string{
+	domain.StatusPending:   {domain.StatusActive, domain.StatusClosed},
+	domain.StatusActive:    {domain.StatusSuspended, domain.StatusClosed},
+	domain.StatusSuspended: {domain.StatusActive, domain.StatusClosed},
+	domain.StatusClosed:    {},
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// initialStatus is the status of a new account. Individuals can sign in right
+// away, a legal entity waits for staff to verify its company.
+func initialStatus(user *domain.User) string {
+	if user.AccountType == domain.AccountLegalEntity {
+		return domain.StatusPending
+	}
+	return domain.StatusActive
+}
+
+func (u *userUsecase) ChangeStatusUsecase(ctx context.Context, actorID int64, username, status, reason string) (*domain.User, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	if _, ok := statusTransitions[status]; !ok {
+		return nil, domain.Validation(domain.CodeUnknownStatus, "unknown status "+status, nil)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" && status != domain.StatusActive {
+		return nil, domain.Validation(domain.CodeInvalidInput, "a reason is required to make an account "+status, nil)
+	}
+	user, err := u.userRepo.GetUserByUsername(context, username)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if !canTransition(user.Status, status) {
+		return nil, domain.Conflict(domain.CodeStatusTransition, fmt.Sprintf("a %s account cannot become %s", user.Status, status), nil)
+	}
+
+	change := &domain.StatusChange{UserID: user.ID, From: user.Status, To: status, ActorID: actorID, Reason: reason}
+	if err := u.userRepo.SetStatusRepo(context, change); errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.Conflict(domain.CodeStatusTransition, "the status of the account changed meanwhile, try again", err)
+	} else if err != nil {
+		return nil, domain.Internal("cannot change account status", err)
+	}
+	logging.Ctx(ctx).Info().Int64("user", user.ID).Int64("actor", actorID).Str("from", change.From).Str("to", change.To).Msg("account status changed")
+	user.Status = status
+	// the repository deletes closed accounts in the same transaction
+	if status == domain.StatusClosed && user.DeletedAt == nil {
+		user.DeletedAt = &change.ChangedAt
+	}
+	return user, nil
+}
+
+func (u *userUsecase) StatusHistoryUsecase(ctx context.Context, id int64) ([]domain.StatusChange, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	changes, err := u.userRepo.ListStatusHistoryRepo(context, id)
+	if err != nil {
+		return nil, domain.Internal("cannot load status history", err)
+	}
+	return changes, nil
+}
+
+// CloseAccountUsecase deletes the account of a user who confirmed it with the password.
+func (u *userUsecase) CloseAccountUsecase(ctx context.Context, id int64, password string) (*domain.User, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	user, err := u.userRepo.GetUserByID(context, id)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	hash, err := u.userRepo.GetPasswordRepo(context, id)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if !utils.ComparePasswordHash(hash, password) {
+		var errs validation.Errors
+		errs.Add("password", validation.NewFieldError("password", validation.CodePasswordIncorrect))
+		return nil, domain.Validation(domain.CodeInvalidInput, "invalid account closure", errs.Err())
+	}
+	if err := u.deleteUser(context, user, id, "closed by the user"); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUserUsecase deletes an account on behalf of staff.
+func (u *userUsecase) DeleteUserUsecase(ctx context.Context, actorID int64, username, reason string) (*domain.User, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.Validation(domain.CodeInvalidInput, "a reason is required to delete an account", nil)
+	}
+	user, err := u.userRepo.GetUserByUsername(context, username)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if err := u.deleteUser(context, user, actorID, reason); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// deleteUser closes the account whatever its status and starts the grace
+// period, the account can no longer be used but its data is kept until then.
+func (u *userUsecase) deleteUser(ctx context.Context, user *domain.User, actorID int64, reason string) error {
+	if user.DeletedAt != nil {
+		return domain.Conflict(domain.CodeUserDeleted, "the account is already deleted", nil)
+	}
+	change := &domain.StatusChange{UserID: user.ID, From: user.Status, To: domain.StatusClosed, ActorID: actorID, Reason: reason}
+	if err := u.userRepo.DeleteUserRepo(ctx, change); errors.Is(err, domain.ErrNotFound) {
+		return domain.Conflict(domain.CodeStatusTransition, "the status of the account changed meanwhile, try again", err)
+	} else if err != nil {
+		return domain.Internal("cannot delete account", err)
+	}
+	logging.Ctx(ctx).Info().Int64("user", user.ID).Int64("actor", actorID).Msg("account deleted")
+	user.Status = domain.StatusClosed
+	user.DeletedAt = &change.ChangedAt
+	return nil
+}
@@ -2,41 +2,60 @@ package usecase
 
 import (
 	"context"
-	"net/http"
+	"errors"
+	"strings"
 	"time"
 	"transaction-service/domain"
+	"transaction-service/iin"
+	"transaction-service/password"
 	utils "transaction-service/utils"
+	"transaction-service/validation"
 )
 
 type userUsecase struct {
 	userRepo       domain.UserRepository
 	timeoutContext time.Duration
+	policy         *password.Policy
+	// minAge is the youngest age allowed to register.
+	minAge int
 }
 
-func NewUserUseCase(repo domain.UserRepository, time time.Duration) domain.UserUsecase {
-	return &userUsecase{userRepo: repo, timeoutContext: time}
+func NewUserUseCase(repo domain.UserRepository, time time.Duration, policy *password.Policy, minAge int) domain.UserUsecase {
+	return &userUsecase{userRepo: repo, timeoutContext: time, policy: policy, minAge: minAge}
 }
 
 func (u *userUsecase) CreateUserUsecase(ctx context.Context, user *domain.User) error {
+	// whatever the form says, roles are only granted by staff afterwards
+	return u.CreateUserWithRoleUsecase(ctx, user, "user")
+}
+
+func (u *userUsecase) CreateUserWithRoleUsecase(ctx context.Context, user *domain.User, role string) error {
 	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
 	defer cancel()
-	if _, err := u.userRepo.GetUserByIIN(context, user.IIN); err == nil {
-		return &domain.LogError{"user already registered by iin", err, http.StatusBadRequest}
+	if !domain.ValidRole(role) {
+		return domain.Validation(domain.CodeUnknownRole, "unknown role "+role, nil)
+	}
+	if user.AccountType == "" {
+		user.AccountType = domain.AccountIndividual
+	}
+	if err := u.validateRegistration(user); err != nil {
+		return domain.Validation(domain.CodeInvalidInput, "invalid registration form", err)
 	}
-	if err := utils.ValidateCreds(user.Username, user.Password, user.IIN); err != nil {
-		return &domain.LogError{err.Error(), err, http.StatusBadRequest}
+	if _, err := u.userRepo.GetUserByIIN(context, user.IIN); err == nil {
+		return domain.Conflict(domain.CodeUserExists, "user already registered by iin", nil)
 	}
 	// TODO: fix ths func
 	hashedPassword := utils.GenerateHash(user.Password)
 	user.Password = hashedPassword
 
-	if user.Role == "" {
-		user.Role = "user"
-	}
+	user.Role = role
+	user.Status = initialStatus(user)
 	user.RegisterDate = time.Now().Format("2006-01-02 15:04:05")
 
-	if err := u.userRepo.CreateUser(context, user); err != nil {
-		return &domain.LogError{"registration error", err, http.StatusInternalServerError}
+	if err := u.userRepo.CreateUser(context, user); errors.Is(err, domain.ErrDuplicate) {
+		return domain.Conflict(domain.CodeUserExists, "username, iin or bin already registered", err)
+	} else if err != nil {
+		return domain.Internal("registration error", err)
 	}
 	return nil
 }
@@ -47,7 +66,7 @@ func (u *userUsecase) GetUserByIDUsecase(ctx context.Context, id int64) (*domain
 
 	user, err := u.userRepo.GetUserByID(context, id)
 	if err != nil {
-		return nil, &domain.LogError{"user not found", err, http.StatusNotFound}
+		return nil, lookupError(err)
 	}
 	return user, nil
 }
@@ -58,7 +77,7 @@ func (u *userUsecase) GetUserByNameUsecase(ctx context.Context, name string) (*d
 
 	user, err := u.userRepo.GetUserByUsername(context, name)
 	if err != nil {
-		return nil, &domain.LogError{"user not found", err, http.StatusNotFound}
+		return nil, lookupError(err)
 	}
 	return user, nil
 }
@@ -69,7 +88,7 @@ func (u *userUsecase) GetUserByIINUsecase(ctx context.Context, iin string) (*dom
 
 	user, err := u.userRepo.GetUserByIIN(context, iin)
 	if err != nil {
-		return nil, &domain.LogError{"user not found", err, http.StatusNotFound}
+		return nil, lookupError(err)
 	}
 	return user, nil
 }
@@ -80,7 +99,7 @@ func (u *userUsecase) GetAllUsecase(ctx context.Context) ([]domain.User, error)
 
 	users, err := u.userRepo.GetAllUsers(context)
 	if err != nil {
-		return nil, &domain.LogError{"GetAllUser error", err, http.StatusInternalServerError}
+		return nil, domain.Internal("GetAllUser error", err)
 	}
 	return users, nil
 }
@@ -90,11 +109,180 @@ func (u *userUsecase) UpgradeUserUsecase(ctx context.Context, username string) e
 	defer cancel()
 
 	if _, err := u.userRepo.GetUserByUsername(context, username); err != nil {
-		return &domain.LogError{"user not found to upgrade role", err, http.StatusBadRequest}
+		return lookupError(err)
 	}
 
 	if err := u.userRepo.UpgradeUserRepo(context, username); err != nil {
-		return &domain.LogError{"cannot upgrade user role", err, http.StatusInternalServerError}
+		return domain.Internal("cannot upgrade user role", err)
 	}
 	return nil
 }
+
+// BootstrapAdminUsecase creates the initial admin unless an admin already exists.
+// It reports whether the account was created.
+func (u *userUsecase) BootstrapAdminUsecase(ctx context.Context, admin *domain.User) (bool, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	count, err := u.userRepo.CountUsersByRole(context, "admin")
+	if err != nil {
+		return false, domain.Internal("cannot check existing admins", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if admin.IIN == "" {
+		return false, domain.Validation(domain.CodeInvalidInput, "invalid bootstrap admin: iin is required", nil)
+	}
+	admin.AccountType, admin.Company = domain.AccountIndividual, nil
+	if err := u.validateRegistration(admin); err != nil {
+		return false, domain.Validation(domain.CodeInvalidInput, "invalid bootstrap admin: "+err.Error(), err)
+	}
+
+	admin.Password = utils.GenerateHash(admin.Password)
+	admin.Role = "admin"
+	admin.Status = domain.StatusActive
+	admin.RegisterDate = time.Now().Format("2006-01-02 15:04:05")
+	if err := u.userRepo.CreateUser(context, admin); err != nil {
+		return false, domain.Internal("cannot create bootstrap admin", err)
+	}
+	return true, nil
+}
+
+func (u *userUsecase) ResetPasswordUsecase(ctx context.Context, username, password string) (*domain.User, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	user, err := u.userRepo.GetUserByUsername(context, username)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if err := u.policy.Validate(password, user.Username, user.IIN); err != nil {
+		return nil, domain.Validation(domain.CodeInvalidInput, err.Error(), err)
+	}
+	if err := u.userRepo.UpdatePasswordRepo(context, user.ID, utils.GenerateHash(password), u.policy.History); err != nil {
+		return nil, domain.Internal("cannot reset password", err)
+	}
+	return user, nil
+}
+
+// ChangePasswordUsecase replaces the password of a user who knows the current
+// one. The new password follows the policy and differs from the recent ones.
+func (u *userUsecase) ChangePasswordUsecase(ctx context.Context, id int64, current, next string) (*domain.User, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	user, err := u.userRepo.GetUserByID(context, id)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	hash, err := u.userRepo.GetPasswordRepo(context, id)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+
+	var errs validation.Errors
+	if !utils.ComparePasswordHash(hash, current) {
+		errs.Add("current_password", validation.NewFieldError("current_password", validation.CodePasswordIncorrect))
+		return nil, domain.Validation(domain.CodeInvalidInput, "invalid password change", errs.Err())
+	}
+	if err := u.policy.Validate(next, user.Username, user.IIN); err != nil {
+		errs.Add("new_password", err)
+		return nil, domain.Validation(domain.CodeInvalidInput, "invalid password change", errs.Err())
+	}
+
+	history, err := u.userRepo.ListPasswordHistoryRepo(context, id, u.policy.History)
+	if err != nil {
+		return nil, domain.Internal("cannot load password history", err)
+	}
+	for _, previous := range append([]string{hash}, history...) {
+		if utils.ComparePasswordHash(previous, next) {
+			errs.Add("new_password", validation.NewFieldError("new_password", validation.CodePasswordReused))
+			return nil, domain.Validation(domain.CodeInvalidInput, "invalid password change", errs.Err())
+		}
+	}
+
+	if err := u.userRepo.UpdatePasswordRepo(context, id, utils.GenerateHash(next), u.policy.History); err != nil {
+		return nil, domain.Internal("cannot change password", err)
+	}
+	return user, nil
+}
+
+func (u *userUsecase) SetRoleUsecase(ctx context.Context, username, role string) (*domain.User, error) {
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	if !domain.ValidRole(role) {
+		return nil, domain.Validation(domain.CodeUnknownRole, "unknown role "+role, nil)
+	}
+	user, err := u.userRepo.GetUserByUsername(context, username)
+	if err != nil {
+		return nil, lookupError(err)
+	}
+	if err := u.userRepo.SetRoleRepo(context, username, role); err != nil {
+		return nil, domain.Internal("cannot set user role", err)
+	}
+	user.Role = role
+	return user, nil
+}
+
+// PasswordExpiredUsecase reports whether the password of user id is older than
+// the max age of role. Roles without a max age are not looked up.
+func (u *userUsecase) PasswordExpiredUsecase(ctx context.Context, id int64, role string) (bool, error) {
+	if _, ok := u.policy.MaxAge[role]; !ok {
+		return false, nil
+	}
+	context, cancel := context.WithTimeout(ctx, u.timeoutContext)
+	defer cancel()
+
+	changedAt, err := u.userRepo.GetPasswordChangedAtRepo(context, id)
+	if err != nil {
+		return false, lookupError(err)
+	}
+	return u.policy.Expired(role, changedAt, time.Now()), nil
+}
+
+// CheckPasswordUsecase reports how a candidate password of user fares against
+// the policy, without storing anything.
+func (u *userUsecase) CheckPasswordUsecase(user *domain.User) password.Report {
+	return u.policy.Check(user.Password, user.Username, user.IIN)
+}
+
+// validateRegistration checks the fields of a new user, who must be old enough.
+// A legal entity account also needs the BIN and the name of its company.
+func (u *userUsecase) validateRegistration(user *domain.User) error {
+	errs, _ := validation.As(utils.ValidateCreds(user.Username, user.Password, user.IIN, user.Email, u.policy))
+	if id, err := iin.Parse(user.IIN); err == nil && id.Age() < u.minAge {
+		errs.Add("iin", validation.NewFieldError("iin", validation.CodeIINUnderage, u.minAge))
+	}
+	switch user.AccountType {
+	case domain.AccountIndividual:
+		user.Company = nil
+	case domain.AccountLegalEntity:
+		company := user.Company
+		if company == nil {
+			company = &domain.Company{}
+			user.Company = company
+		}
+		company.Name = strings.TrimSpace(company.Name)
+		if company.Name == "" {
+			errs.Add("company_name", validation.NewFieldError("company_name", validation.CodeRequired))
+		}
+		if bin, err := iin.ParseBIN(company.BIN); err != nil {
+			errs.Add("bin", err)
+		} else {
+			company.RegisteredAt = bin.Registered()
+		}
+	default:
+		errs.Add("account_type", validation.NewFieldError("account_type", validation.CodeAccountType))
+	}
+	return errs.Err()
+}
+
+// lookupError classifies a failed user lookup, only a missing row is a not found.
+func lookupError(err error) error {
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.NotFound(domain.CodeUserNotFound, "user not found", err)
+	}
+	return domain.Internal("cannot load user", err)
+}
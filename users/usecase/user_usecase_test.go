@@ -10,8 +10,11 @@ import (
 
 	"transaction-service/domain"
 	"transaction-service/domain/mocks"
+	"transaction-service/iin"
+	"transaction-service/password"
 	ucase "transaction-service/users/usecase"
 	utils "transaction-service/utils"
+	"transaction-service/validation"
 )
 
 func TestCreateUser(t *testing.T) {
@@ -20,17 +23,20 @@ func TestCreateUser(t *testing.T) {
 		Username: "jack",
 		Password: "QWEqwe123!!@#",
 		IIN:      "940217450216",
+		// a role sent with the signup form is ignored
+		Role: "admin",
 	}
 
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.On("GetUserByIIN", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("no rows in result set")).Once()
-		err := utils.ValidateCreds(mockUser.Username, mockUser.Password, mockUser.IIN)
+		err := utils.ValidateCreds(mockUser.Username, mockUser.Password, mockUser.IIN, mockUser.Email, password.DefaultPolicy())
 		assert.NoError(t, err)
-		mockUserRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil).Once()
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		mockUserRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *domain.User) bool { return user.Role == "user" })).Return(nil).Once()
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 		err = u.CreateUserUsecase(context.Background(), mockUser)
 
 		assert.NoError(t, err)
+		assert.Equal(t, "user", mockUser.Role)
 
 		mockUserRepo.AssertExpectations(t)
 	})
@@ -41,8 +47,81 @@ func TestCreateUser(t *testing.T) {
 			IIN:      "940217450216",
 		}
 		mockUserRepo.On("GetUserByIIN",mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("no rows in result set")).Once()
-		err := utils.ValidateCreds(newMockUser.Username, newMockUser.Password, newMockUser.IIN)
-		assert.EqualError(t, err, "password must contain at least 1 digit, 1 uppercase and 1 lowercase letter")
+		err := utils.ValidateCreds(newMockUser.Username, newMockUser.Password, newMockUser.IIN, newMockUser.Email, password.DefaultPolicy())
+		assert.EqualError(t, err, "password: password must contain an uppercase letter; password: password must contain a digit")
+	})
+	t.Run("error-underage", func(t *testing.T) {
+		child := &domain.User{Username: "timur", Password: "QWEqwe123!!@#", IIN: "150310500008"}
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		err := u.CreateUserUsecase(context.Background(), child)
+
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+		fields, _ := validation.As(err)
+		if assert.Len(t, fields, 1) {
+			assert.Equal(t, validation.CodeIINUnderage, fields[0].Code)
+			assert.Equal(t, "you must be at least 18 years old to register", fields[0].Message)
+		}
+	})
+	t.Run("legal-entity", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		representative := &domain.User{
+			Username:    "aigerim",
+			Password:    "QWEqwe123!!@#",
+			IIN:         "940217450216",
+			AccountType: domain.AccountLegalEntity,
+			Company:     &domain.Company{BIN: "080640000124", Name: " ACME LLP "},
+		}
+		mockUserRepo.On("GetUserByIIN", mock.Anything, representative.IIN).Return(nil, domain.ErrNotFound).Once()
+		mockUserRepo.On("CreateUser", mock.Anything, representative).Return(nil).Once()
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+
+		assert.NoError(t, u.CreateUserUsecase(context.Background(), representative))
+		assert.Equal(t, domain.StatusPending, representative.Status)
+		assert.Equal(t, "ACME LLP", representative.Company.Name)
+		assert.Equal(t, time.Date(2008, time.June, 1, 0, 0, 0, 0, time.UTC), representative.Company.RegisteredAt)
+
+		// an IIN in place of the BIN
+		err := u.CreateUserUsecase(context.Background(), &domain.User{
+			Username:    "aigerim",
+			Password:    "QWEqwe123!!@#",
+			IIN:         "940217450216",
+			AccountType: domain.AccountLegalEntity,
+			Company:     &domain.Company{BIN: "940217450216"},
+		})
+		fields, _ := validation.As(err)
+		assert.ElementsMatch(t, []string{"company_name", "bin"}, []string{fields[0].Field, fields[1].Field})
+		assert.True(t, fields.Has("bin"))
+
+		err = u.CreateUserUsecase(context.Background(), &domain.User{Username: "aigerim", Password: "QWEqwe123!!@#", IIN: "940217450216", AccountType: "partnership"})
+		fields, _ = validation.As(err)
+		assert.True(t, fields.Has("account_type"))
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestCreateUserWithRoleUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+
+	t.Run("success", func(t *testing.T) {
+		user := &domain.User{Username: "support1", Password: "QWEqwe123!!@#", IIN: "940217450216"}
+		mockUserRepo.On("GetUserByIIN", mock.Anything, user.IIN).Return(nil, domain.ErrNotFound).Once()
+		// the role goes in with the insert, there is no window with the default role
+		mockUserRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *domain.User) bool { return user.Role == "support" })).Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		err := u.CreateUserWithRoleUsecase(context.Background(), user, "support")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "support", user.Role)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		err := u.CreateUserWithRoleUsecase(context.Background(), &domain.User{Username: "root"}, "root")
+
+		assert.Equal(t, domain.CodeUnknownRole, domain.CodeOf(err))
+		mockUserRepo.AssertExpectations(t)
 	})
 }
 
@@ -60,7 +139,7 @@ func TestGetUserByIDUsecase(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.On("GetUserByID", mock.Anything, mock.AnythingOfType("int64")).Return(mockUser, nil).Once()
 
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 
 		a, err := u.GetUserByIDUsecase(context.Background(), mockUser.ID)
 
@@ -72,7 +151,7 @@ func TestGetUserByIDUsecase(t *testing.T) {
 	t.Run("error-failed", func(t *testing.T) {
 		mockUserRepo.On("GetUserByID", mock.Anything, mock.AnythingOfType("int64")).Return(&domain.User{}, errors.New("Unexpected")).Once()
 
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 
 		a, err := u.GetUserByIDUsecase(context.Background(), mockUser.ID)
 
@@ -97,7 +176,7 @@ func TestGetUserByNameUsecase(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.On("GetUserByUsername", mock.Anything, mock.AnythingOfType("string")).Return(mockUser, nil).Once()
 
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 
 		a, err := u.GetUserByNameUsecase(context.Background(), mockUser.Username)
 
@@ -109,7 +188,7 @@ func TestGetUserByNameUsecase(t *testing.T) {
 	t.Run("error-failed", func(t *testing.T) {
 		mockUserRepo.On("GetUserByUsername", mock.Anything, mock.AnythingOfType("string")).Return(&domain.User{}, errors.New("Unexpected")).Once()
 
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 
 		a, err := u.GetUserByNameUsecase(context.Background(), mockUser.Username)
 
@@ -144,7 +223,7 @@ func TestGetAllUsecase(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.On("GetAllUsers", mock.Anything).Return(mockUser, nil).Once()
 
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 
 		a, err := u.GetAllUsecase(context.Background())
 
@@ -156,7 +235,7 @@ func TestGetAllUsecase(t *testing.T) {
 	t.Run("error-failed", func(t *testing.T) {
 		mockUserRepo.On("GetAllUsers", mock.Anything).Return([]domain.User{}, errors.New("Unexpected")).Once()
 
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 
 		a, err := u.GetAllUsecase(context.Background())
 
@@ -172,9 +251,10 @@ func TestUpgradeUserUsecase(t *testing.T) {
 	username := "nazerke"
 
 	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{Username: username}, nil).Once()
 		mockUserRepo.On("UpgradeUserRepo", mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
 
-		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second)
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
 
 		err := u.UpgradeUserUsecase(context.Background(), username)
 		assert.NoError(t, err)
@@ -182,3 +262,344 @@ func TestUpgradeUserUsecase(t *testing.T) {
 		mockUserRepo.AssertExpectations(t)
 	})
 }
+
+func TestBootstrapAdminUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+
+	t.Run("success", func(t *testing.T) {
+		admin := &domain.User{Username: "root", IIN: "990824351277", Password: "Qwe123!@"}
+		mockUserRepo.On("CountUsersByRole", mock.Anything, "admin").Return(int64(0), nil).Once()
+		mockUserRepo.On("CreateUser", mock.Anything, admin).Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		created, err := u.BootstrapAdminUsecase(context.Background(), admin)
+
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "admin", admin.Role)
+		assert.True(t, utils.ComparePasswordHash(admin.Password, "Qwe123!@"))
+
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("admin-exists", func(t *testing.T) {
+		mockUserRepo.On("CountUsersByRole", mock.Anything, "admin").Return(int64(1), nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		created, err := u.BootstrapAdminUsecase(context.Background(), &domain.User{Username: "root"})
+
+		assert.NoError(t, err)
+		assert.False(t, created)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockUserRepo.On("CountUsersByRole", mock.Anything, "admin").Return(int64(0), nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		created, err := u.BootstrapAdminUsecase(context.Background(), &domain.User{Username: "root", IIN: "940217200216", Password: "Qwe123!@"})
+
+		assert.Error(t, err)
+		assert.False(t, created)
+
+		mockUserRepo.On("CountUsersByRole", mock.Anything, "admin").Return(int64(0), nil).Once()
+		_, err = u.BootstrapAdminUsecase(context.Background(), &domain.User{Username: "root", Password: "Qwe123!@"})
+
+		assert.EqualError(t, err, "invalid bootstrap admin: iin is required")
+
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestResetPasswordUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	username := "nazerke"
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{ID: 3, Username: username}, nil).Once()
+		mockUserRepo.On("UpdatePasswordRepo", mock.Anything, int64(3), mock.MatchedBy(func(hash string) bool {
+			return utils.ComparePasswordHash(hash, "Qwe123!@")
+		}), 5).Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		user, err := u.ResetPasswordUsecase(context.Background(), username, "Qwe123!@")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), user.ID)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{ID: 3, Username: username}, nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		_, err := u.ResetPasswordUsecase(context.Background(), username, "weak")
+
+		assert.Error(t, err)
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestChangePasswordUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	user := &domain.User{ID: 3, Username: "nazerke", IIN: "940217450216"}
+	current := utils.GenerateHash("Qwe123!@")
+	u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetUserByID", mock.Anything, int64(3)).Return(user, nil).Once()
+		mockUserRepo.On("GetPasswordRepo", mock.Anything, int64(3)).Return(current, nil).Once()
+		mockUserRepo.On("ListPasswordHistoryRepo", mock.Anything, int64(3), 5).Return([]string{utils.GenerateHash("Old123!@")}, nil).Once()
+		mockUserRepo.On("UpdatePasswordRepo", mock.Anything, int64(3), utils.GenerateHash("New456#$"), 5).Return(nil).Once()
+
+		changed, err := u.ChangePasswordUsecase(context.Background(), 3, "Qwe123!@", "New456#$")
+
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, changed.ID)
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		cases := map[string]struct {
+			current, next, field, code string
+		}{
+			"wrong current": {"Wrong1!@", "New456#$", "current_password", validation.CodePasswordIncorrect},
+			"policy":        {"Qwe123!@", "new", "new_password", validation.CodePasswordTooShort},
+			"same":          {"Qwe123!@", "Qwe123!@", "new_password", validation.CodePasswordReused},
+			"history":       {"Qwe123!@", "Old123!@", "new_password", validation.CodePasswordReused},
+		}
+		for name, c := range cases {
+			mockUserRepo.On("GetUserByID", mock.Anything, int64(3)).Return(user, nil).Once()
+			mockUserRepo.On("GetPasswordRepo", mock.Anything, int64(3)).Return(current, nil).Once()
+			mockUserRepo.On("ListPasswordHistoryRepo", mock.Anything, int64(3), 5).Return([]string{utils.GenerateHash("Old123!@")}, nil).Maybe()
+
+			_, err := u.ChangePasswordUsecase(context.Background(), 3, c.current, c.next)
+
+			assert.Equal(t, domain.KindValidation, domain.KindOf(err), name)
+			fields, _ := validation.As(err)
+			if assert.NotEmpty(t, fields, name) {
+				assert.Equal(t, c.field, fields[0].Field, name)
+				assert.Equal(t, c.code, fields[0].Code, name)
+			}
+		}
+		mockUserRepo.AssertNotCalled(t, "UpdatePasswordRepo", mock.Anything, int64(3), utils.GenerateHash("Old123!@"), 5)
+	})
+}
+
+func TestPasswordExpiredUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	policy := password.DefaultPolicy()
+	policy.MaxAge = map[string]time.Duration{"admin": 90 * 24 * time.Hour}
+	u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, policy, 18)
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetPasswordChangedAtRepo", mock.Anything, int64(1)).Return(time.Now().AddDate(0, 0, -100), nil).Once()
+		expired, err := u.PasswordExpiredUsecase(context.Background(), 1, "admin")
+		assert.NoError(t, err)
+		assert.True(t, expired)
+
+		mockUserRepo.On("GetPasswordChangedAtRepo", mock.Anything, int64(1)).Return(time.Now().AddDate(0, 0, -10), nil).Once()
+		expired, err = u.PasswordExpiredUsecase(context.Background(), 1, "admin")
+		assert.NoError(t, err)
+		assert.False(t, expired)
+
+		// roles without a max age are not looked up
+		expired, err = u.PasswordExpiredUsecase(context.Background(), 2, "user")
+		assert.NoError(t, err)
+		assert.False(t, expired)
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockUserRepo.On("GetPasswordChangedAtRepo", mock.Anything, int64(1)).Return(time.Time{}, errors.New("connection refused")).Once()
+		_, err := u.PasswordExpiredUsecase(context.Background(), 1, "admin")
+		assert.Equal(t, domain.KindInternal, domain.KindOf(err))
+	})
+}
+
+func TestSetRoleUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	username := "nazerke"
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{Username: username, Role: "user"}, nil).Once()
+		mockUserRepo.On("SetRoleRepo", mock.Anything, username, "support").Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		user, err := u.SetRoleUsecase(context.Background(), username, "support")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "support", user.Role)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		_, err := u.SetRoleUsecase(context.Background(), username, "root")
+
+		assert.Error(t, err)
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+	})
+}
+
+func TestChangeStatusUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	username := "nazerke"
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{ID: 5, Username: username, Status: domain.StatusActive}, nil).Once()
+		mockUserRepo.On("SetStatusRepo", mock.Anything, &domain.StatusChange{UserID: 5, From: domain.StatusActive, To: domain.StatusSuspended, ActorID: 1, Reason: "chargebacks"}).Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		user, err := u.ChangeStatusUsecase(context.Background(), 1, username, domain.StatusSuspended, " chargebacks ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StatusSuspended, user.Status)
+		assert.Nil(t, user.DeletedAt)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("success-closed", func(t *testing.T) {
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{ID: 5, Username: username, Status: domain.StatusSuspended}, nil).Once()
+		mockUserRepo.On("SetStatusRepo", mock.Anything, &domain.StatusChange{UserID: 5, From: domain.StatusSuspended, To: domain.StatusClosed, ActorID: 1, Reason: "fraud"}).
+			Run(func(args mock.Arguments) { args.Get(1).(*domain.StatusChange).ChangedAt = time.Now() }).Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		user, err := u.ChangeStatusUsecase(context.Background(), 1, username, domain.StatusClosed, "fraud")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StatusClosed, user.Status)
+		// closing starts the grace period before the erasure
+		assert.NotNil(t, user.DeletedAt)
+
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+
+		_, err := u.ChangeStatusUsecase(context.Background(), 1, username, "deleted", "reason")
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+		_, err = u.ChangeStatusUsecase(context.Background(), 1, username, domain.StatusSuspended, "")
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+
+		// a closed account stays closed
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{ID: 5, Username: username, Status: domain.StatusClosed}, nil).Once()
+		_, err = u.ChangeStatusUsecase(context.Background(), 1, username, domain.StatusActive, "")
+		assert.True(t, errors.Is(err, &domain.Error{Code: domain.CodeStatusTransition}))
+
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{ID: 5, Username: username, Status: domain.StatusPending}, nil).Once()
+		_, err = u.ChangeStatusUsecase(context.Background(), 1, username, domain.StatusSuspended, "fraud")
+		assert.Equal(t, domain.KindConflict, domain.KindOf(err))
+
+		// changed by someone else in between
+		mockUserRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{ID: 5, Username: username, Status: domain.StatusPending}, nil).Once()
+		mockUserRepo.On("SetStatusRepo", mock.Anything, mock.AnythingOfType("*domain.StatusChange")).Return(domain.ErrNotFound).Once()
+		_, err = u.ChangeStatusUsecase(context.Background(), 1, username, domain.StatusActive, "")
+		assert.Equal(t, domain.KindConflict, domain.KindOf(err))
+
+		mockUserRepo.On("GetUserByUsername", mock.Anything, "unknown").Return(nil, domain.ErrNotFound).Once()
+		_, err = u.ChangeStatusUsecase(context.Background(), 1, "unknown", domain.StatusActive, "")
+		assert.Equal(t, domain.KindNotFound, domain.KindOf(err))
+
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestStatusError(t *testing.T) {
+	assert.NoError(t, domain.StatusError(&domain.User{Status: domain.StatusActive}))
+	assert.True(t, errors.Is(domain.StatusError(&domain.User{Status: domain.StatusPending}), &domain.Error{Code: domain.CodeAccountPending}))
+	assert.True(t, errors.Is(domain.StatusError(&domain.User{Status: domain.StatusSuspended}), &domain.Error{Code: domain.CodeAccountLocked}))
+	assert.True(t, errors.Is(domain.StatusError(&domain.User{Status: domain.StatusClosed}), &domain.Error{Code: domain.CodeAccountClosed}))
+}
+
+func TestUserInfoPresent(t *testing.T) {
+	info := domain.UserInfo{
+		User:     domain.User{ID: 7, IIN: "940217450216", Password: "hash"},
+		Accounts: []domain.Accounts{{Number: "KZ0012345678", Balance: 1500, RegisterDate: "2021-01-02"}},
+	}
+
+	shown := info.Present(domain.User{ID: 1, Role: "admin"})
+	assert.Equal(t, "940217450216", shown.User.IIN)
+	assert.Empty(t, shown.User.Password)
+	assert.Equal(t, info.Accounts, shown.Accounts)
+
+	// the owner sees the own record
+	shown = info.Present(domain.User{ID: 7, Role: "user"})
+	assert.Equal(t, "940217450216", shown.User.IIN)
+	assert.Equal(t, info.Accounts, shown.Accounts)
+
+	shown = info.Present(domain.User{ID: 8, Role: "support"})
+	assert.Equal(t, "9402******16", shown.User.IIN)
+	assert.Equal(t, []domain.Accounts{{Number: "KZ00******78", RegisterDate: "2021-01-02", Hidden: true}}, shown.Accounts)
+	// what the IIN encodes is kept when it is masked
+	if assert.NotNil(t, shown.Identity) {
+		assert.Equal(t, time.Date(1994, time.February, 17, 0, 0, 0, 0, time.UTC), shown.Identity.BirthDate)
+		assert.Equal(t, iin.Female, shown.Identity.Gender)
+	}
+
+	// support impersonating the owner or an admin does not gain the permission
+	shown = info.Present(domain.User{ID: 7, Role: "user", Actor: &domain.Actor{ID: 3, Role: "support"}})
+	assert.Equal(t, "9402******16", shown.User.IIN)
+	shown = info.Present(domain.User{ID: 1, Role: "admin", Actor: &domain.Actor{ID: 3, Role: "support"}})
+	assert.Equal(t, "9402******16", shown.User.IIN)
+	assert.Equal(t, "940217450216", info.User.IIN)
+	assert.Equal(t, int64(1500), info.Accounts[0].Balance)
+}
+
+func TestCloseAccountUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	user := &domain.User{ID: 25, Username: "content", Status: domain.StatusActive}
+	hash := utils.GenerateHash("Qwe123@!")
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.On("GetUserByID", mock.Anything, user.ID).Return(user, nil).Once()
+		mockUserRepo.On("GetPasswordRepo", mock.Anything, user.ID).Return(hash, nil).Once()
+		mockUserRepo.On("DeleteUserRepo", mock.Anything, &domain.StatusChange{UserID: 25, From: domain.StatusActive, To: domain.StatusClosed, ActorID: 25, Reason: "closed by the user"}).Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		closed, err := u.CloseAccountUsecase(context.Background(), user.ID, "Qwe123@!")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StatusClosed, closed.Status)
+		assert.NotNil(t, closed.DeletedAt)
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		mockUserRepo.On("GetUserByID", mock.Anything, int64(26)).Return(&domain.User{ID: 26, Status: domain.StatusActive}, nil).Once()
+		mockUserRepo.On("GetPasswordRepo", mock.Anything, int64(26)).Return(hash, nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		_, err := u.CloseAccountUsecase(context.Background(), 26, "wrong")
+		fields, ok := validation.As(err)
+		assert.True(t, ok)
+		assert.True(t, fields.Has("password"))
+		mockUserRepo.AssertExpectations(t)
+	})
+}
+
+func TestDeleteUserUsecase(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+
+	t.Run("success", func(t *testing.T) {
+		// suspended accounts can be deleted too
+		mockUserRepo.On("GetUserByUsername", mock.Anything, "nazerke").Return(&domain.User{ID: 5, Username: "nazerke", Status: domain.StatusSuspended}, nil).Once()
+		mockUserRepo.On("DeleteUserRepo", mock.Anything, &domain.StatusChange{UserID: 5, From: domain.StatusSuspended, To: domain.StatusClosed, ActorID: 1, Reason: "erasure request"}).Return(nil).Once()
+
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		user, err := u.DeleteUserUsecase(context.Background(), 1, "nazerke", "erasure request")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StatusClosed, user.Status)
+		mockUserRepo.AssertExpectations(t)
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		u := ucase.NewUserUseCase(mockUserRepo, 2*time.Second, password.DefaultPolicy(), 18)
+		_, err := u.DeleteUserUsecase(context.Background(), 1, "nazerke", " ")
+		assert.Equal(t, domain.KindValidation, domain.KindOf(err))
+
+		deletedAt := time.Now()
+		mockUserRepo.On("GetUserByUsername", mock.Anything, "nazerke").Return(&domain.User{ID: 5, Status: domain.StatusClosed, DeletedAt: &deletedAt}, nil).Once()
+		_, err = u.DeleteUserUsecase(context.Background(), 1, "nazerke", "again")
+		assert.True(t, errors.Is(err, &domain.Error{Code: domain.CodeUserDeleted}))
+		mockUserRepo.AssertExpectations(t)
+	})
+}
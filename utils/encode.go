@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
+	"math/big"
+	"transaction-service/password"
 )
 
 func GenerateHash(password string) string {
@@ -11,10 +14,33 @@ func GenerateHash(password string) string {
 
 func ComparePasswordHash(pass1, pass2 string) bool {
 	passFromClient := GenerateHash(pass2)
+	return passFromClient == pass1
+}
 
-	if pass1 == "pass" {
-		adminpass := GenerateHash(pass1)
-		return adminpass == passFromClient
+// generatedPolicy requires every character class so generated passwords pass
+// any configured policy of at most their length.
+var generatedPolicy = &password.Policy{
+	RequireLower:   true,
+	RequireUpper:   true,
+	RequireDigit:   true,
+	RequireSpecial: true,
+	MinScore:       password.ScoreStrong,
+}
+
+// GeneratePassword returns a random password of the given length that satisfies the password rules.
+func GeneratePassword(length int) (string, error) {
+	const alphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!@#%*-_+=?"
+	for {
+		pass := make([]byte, length)
+		for i := range pass {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return "", err
+			}
+			pass[i] = alphabet[n.Int64()]
+		}
+		if generatedPolicy.Validate(string(pass)) == nil {
+			return string(pass), nil
+		}
 	}
-	return passFromClient == pass1
 }
@@ -19,4 +19,18 @@ func TestComparePasswordHash(t *testing.T) {
 	if !res {
 		t.Errorf("want: true, got: %v", res)
 	} 
-}
\ No newline at end of file
+}
+func TestGeneratePassword(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		pass, err := GeneratePassword(16)
+		if err != nil {
+			t.Fatalf("want: nil, got: %v", err)
+		}
+		if len(pass) != 16 {
+			t.Errorf("want: 16 characters, got: %v", len(pass))
+		}
+		if err := generatedPolicy.Validate(pass); err != nil {
+			t.Errorf("want: valid password, got: %v", err)
+		}
+	}
+}
@@ -1,80 +1,56 @@
 package utils
 
 import (
-	"fmt"
-	"strconv"
+	"net/mail"
+	"transaction-service/iin"
+	"transaction-service/password"
+	"transaction-service/validation"
 )
 
-func ValidateCreds(username, password, iin string) error {
-	if err := checkUsername(username); err != nil {
-		return err
+// ValidateCreds checks every registration field, the password against policy,
+// and returns all the failures as validation.Errors. The email is optional.
+func ValidateCreds(username, pass, iin, email string, policy *password.Policy) error {
+	var errs validation.Errors
+	if username == "" {
+		errs.Add("username", validation.NewFieldError("username", validation.CodeRequired))
+	} else {
+		errs.Add("username", checkUsername(username))
 	}
-	if err := checkPassword(password); err != nil {
-		return err
+	if pass == "" {
+		errs.Add("password", validation.NewFieldError("password", validation.CodeRequired))
+	} else {
+		errs.Add("password", policy.Validate(pass, username, iin))
 	}
-	if err := checkIIN(iin); err != nil {
-		return err
+	if iin == "" {
+		errs.Add("iin", validation.NewFieldError("iin", validation.CodeRequired))
+	} else {
+		errs.Add("iin", checkIIN(iin))
 	}
-	return nil
+	if email != "" {
+		errs.Add("email", checkEmail(email))
+	}
+	return errs.Err()
 }
 
 func checkUsername(name string) error {
 	for _, letter := range name {
 		if !isNumeric(letter) && !isAlpha(letter) {
-			return fmt.Errorf("username must contain only lowercase letters and digits")
+			return validation.NewFieldError("username", validation.CodeUsernameChars)
 		}
 	}
 	return nil
 }
 
-func checkPassword(pass string) error {
-	var countDigit, countLower, countUpper int
-	if len(pass) < 6 {
-		return fmt.Errorf("password must be at least 6 characters in length")
-	}
-
-	for _, letter := range pass {
-		if isNumeric(letter) {
-			countDigit++
-			continue
-		}
-		if isAlpha(letter) {
-			countLower++
-			continue
-		}
-		if letter >= 65 && letter <= 90 {
-			countUpper++
-			continue
-		}
-		if !isSpecialChar(letter) {
-			return fmt.Errorf("password must contain at least 1 digit, 1 uppercase and 1 lowercase letter and special characters")
-		}
-	}
-	if countDigit < 1 || countLower < 1 || countUpper < 1 {
-		return fmt.Errorf("password must contain at least 1 digit, 1 uppercase and 1 lowercase letter")
-	}
-	return nil
+func checkIIN(s string) error {
+	_, err := iin.Parse(s)
+	return err
 }
 
-func checkIIN(iin string) error {
-	if len(iin) != 12 {
-		return fmt.Errorf("invalid IIN: length is not 12")
-	}
-	iinDigits, err := atoi(iin)
-
-	if iinDigits[6] < 3 || iinDigits[6] > 6 {
-		return fmt.Errorf("invalid IIN: 7 digit incorrect")
-	}
-
-	if err != nil {
-		return fmt.Errorf("convert IIN error")
-	}
-	res := checkTwelve(iinDigits, 1)
-	if res == 10 {
-		res = checkTwelve(iinDigits, 3)
-	}
-	if iinDigits[11] != res {
-		return fmt.Errorf("invalid IIN: 12 digit incorrect")
+func checkEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	// a bare address only, "Name <user@host>" is not an email field value
+	if err != nil || addr.Address != email {
+		return validation.NewFieldError("email", validation.CodeEmailInvalid)
 	}
 	return nil
 }
@@ -86,35 +62,3 @@ func isNumeric(letter rune) bool {
 func isAlpha(letter rune) bool {
 	return letter >= 97 && letter <= 122
 }
-
-func isSpecialChar(letter rune) bool {
-	special := "~!@#$%^&*_-+=`|\\(){}[]:;\"'<>,.?/"
-	for _, char := range special {
-		if char == letter {
-			return true
-		}
-	}
-	return false
-}
-
-func atoi(iin string) ([]int, error) {
-	res := []int{}
-	for _, i := range iin {
-		digit, err := strconv.Atoi(string(i))
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, digit)
-	}
-	return res, nil
-}
-
-func checkTwelve(iin []int, start int) int {
-	res := 0
-	for i := 0; i < 10; i++ {
-		res += start * int(iin[i])
-		start++
-	}
-
-	return res % 11
-}
@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"testing"
+	"transaction-service/password"
+	"transaction-service/validation"
 )
 
 func TestCheckIIN(t *testing.T) {
@@ -15,14 +17,6 @@ func TestCheckIIN(t *testing.T) {
 	}
 }
 
-func TestCheckTwelve(t *testing.T) {
-	iin := []int{9, 4, 0, 2, 1, 7, 4, 5, 0, 2, 1, 6}
-	res := checkTwelve(iin, 1)
-	if res != 6 {
-		t.Errorf("want: 6, got: %v", res)
-	}
-}
-
 func TestUsername(t *testing.T) {
 	names := []string{"Albina", "medi", "hahaha1", "qwe!@#"}
 	results := []error{fmt.Errorf("username must contain only lowercase letters and digits"), nil, nil, fmt.Errorf("username must contain only lowercase letters and digits")}
@@ -36,41 +30,40 @@ func TestUsername(t *testing.T) {
 
 }
 
-func TestCheckPassword(t *testing.T) {
-	password := []string{"asdasad", "Asd123@", "Qwe123!@!", "123456", "1234"}
-	result := []error{fmt.Errorf("password must contain at least 1 digit, 1 uppercase and 1 lowercase letter"),
-		nil, nil, fmt.Errorf("password must contain at least 1 digit, 1 uppercase and 1 lowercase letter"),
-		fmt.Errorf("password must be at least 6 characters in length")}
-	for i := 0; i < 5; i++ {
-		res := checkPassword(password[i])
-		if res != result[i] {
-			t.Errorf("want: %v, got: %v", result[i], res)
-		}
-	}
-
-}
-
-func TestIsSpecialChar(t *testing.T) {
+func TestIsAlpha(t *testing.T) {
 
 	// word := "Asd123@"
 	word := "Qwe123!@!"
 	for i, w := range word {
-		res := isSpecialChar(w)
+		res := isAlpha(w)
 		if !res {
 			t.Errorf("want: false %v, got: %v", i, res)
 		}
 	}
-
 }
 
-func TestIsAlpha(t *testing.T) {
+func TestValidateCreds(t *testing.T) {
+	if err := ValidateCreds("medi", "Qwe123!@!", "990824351277", "", password.DefaultPolicy()); err != nil {
+		t.Errorf("want: nil, got: %v", err)
+	}
 
-	// word := "Asd123@"
-	word := "Qwe123!@!"
-	for i, w := range word {
-		res := isAlpha(w)
-		if !res {
-			t.Errorf("want: false %v, got: %v", i, res)
+	err := ValidateCreds("Albina", "1234", "99082435127x", "albina@", password.DefaultPolicy())
+	fields, ok := validation.As(err)
+	if !ok {
+		t.Fatalf("want: validation errors, got: %v", err)
+	}
+	codes := []string{validation.CodeUsernameChars, validation.CodePasswordTooShort, validation.CodePasswordLower, validation.CodePasswordUpper, validation.CodeIINDigits, validation.CodeEmailInvalid}
+	if len(fields) != len(codes) {
+		t.Fatalf("want: %d field errors, got: %v", len(codes), fields)
+	}
+	for i, code := range codes {
+		if fields[i].Code != code {
+			t.Errorf("want: %v, got: %v", code, fields[i].Code)
 		}
 	}
+
+	fields, _ = validation.As(ValidateCreds("", "", "", "", password.DefaultPolicy()))
+	if len(fields) != 3 || fields[0].Code != validation.CodeRequired {
+		t.Errorf("want: 3 required fields, got: %v", fields)
+	}
 }
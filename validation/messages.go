@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultLanguage is used when the client accepts none of the catalog languages.
+const DefaultLanguage = "en"
+
+// catalog holds the field error messages by language and code.
+var catalog = map[string]map[string]string{
+	"en": {
+		CodeRequired:          "this field is required",
+		CodeUsernameChars:     "username must contain only lowercase letters and digits",
+		CodePasswordTooShort:  "password must be at least %d characters in length",
+		CodePasswordTooLong:   "password must be at most %d characters in length",
+		CodePasswordLower:     "password must contain a lowercase letter",
+		CodePasswordUpper:     "password must contain an uppercase letter",
+		CodePasswordDigit:     "password must contain a digit",
+		CodePasswordSpecial:   "password must contain a special character",
+		CodePasswordChars:     "password contains characters that are not allowed",
+		CodePasswordPersonal:  "password must not contain your username or IIN",
+		CodePasswordBreached:  "password is too common, choose another one",
+		CodePasswordWeak:      "password is too easy to guess, make it longer or less predictable",
+		CodePasswordReused:    "password was used recently, choose another one",
+		CodePasswordMismatch:  "passwords do not match",
+		CodePasswordIncorrect: "current password is incorrect",
+		CodeIINLength:         "invalid IIN: length is not 12",
+		CodeIINDigits:         "invalid IIN: must contain only digits",
+		CodeIINCentury:        "invalid IIN: 7 digit incorrect",
+		CodeIINDate:           "invalid IIN: the birth date does not exist",
+		CodeIINUnderage:       "you must be at least %d years old to register",
+		CodeIINChecksum:       "invalid IIN: 12 digit incorrect",
+		CodeBINLength:         "invalid BIN: length is not 12",
+		CodeBINDigits:         "invalid BIN: must contain only digits",
+		CodeBINEntity:         "invalid BIN: 5 or 6 digit incorrect",
+		CodeBINDate:           "invalid BIN: the registration date does not exist",
+		CodeBINChecksum:       "invalid BIN: 12 digit incorrect",
+		CodeAccountType:       "unknown account type",
+		CodeEmailInvalid:      "invalid email address",
+	},
+	"ru": {
+		CodeRequired:          "обязательное поле",
+		CodeUsernameChars:     "имя пользователя может содержать только строчные латинские буквы и цифры",
+		CodePasswordTooShort:  "пароль должен содержать не менее %d символов",
+		CodePasswordTooLong:   "пароль должен содержать не более %d символов",
+		CodePasswordLower:     "пароль должен содержать строчную букву",
+		CodePasswordUpper:     "пароль должен содержать заглавную букву",
+		CodePasswordDigit:     "пароль должен содержать цифру",
+		CodePasswordSpecial:   "пароль должен содержать специальный символ",
+		CodePasswordChars:     "пароль содержит недопустимые символы",
+		CodePasswordPersonal:  "пароль не должен содержать имя пользователя или ИИН",
+		CodePasswordBreached:  "пароль слишком распространён, выберите другой",
+		CodePasswordWeak:      "пароль легко подобрать, сделайте его длиннее или менее предсказуемым",
+		CodePasswordReused:    "пароль использовался недавно, выберите другой",
+		CodePasswordMismatch:  "пароли не совпадают",
+		CodePasswordIncorrect: "неверный текущий пароль",
+		CodeIINLength:         "ИИН должен состоять из 12 цифр",
+		CodeIINDigits:         "ИИН должен содержать только цифры",
+		CodeIINCentury:        "неверная 7-я цифра ИИН",
+		CodeIINDate:           "ИИН содержит несуществующую дату рождения",
+		CodeIINUnderage:       "для регистрации вам должно быть не меньше %d лет",
+		CodeIINChecksum:       "неверная контрольная цифра ИИН",
+		CodeBINLength:         "БИН должен состоять из 12 цифр",
+		CodeBINDigits:         "БИН должен содержать только цифры",
+		CodeBINEntity:         "неверная 5-я или 6-я цифра БИН",
+		CodeBINDate:           "БИН содержит несуществующую дату регистрации",
+		CodeBINChecksum:       "неверная контрольная цифра БИН",
+		CodeAccountType:       "неизвестный тип учетной записи",
+		CodeEmailInvalid:      "неверный адрес электронной почты",
+	},
+	"kk": {
+		CodeRequired:          "міндетті өріс",
+		CodeUsernameChars:     "пайдаланушы аты тек кіші латын әріптері мен цифрлардан тұруы керек",
+		CodePasswordTooShort:  "құпиясөз кемінде %d таңбадан тұруы керек",
+		CodePasswordTooLong:   "құпиясөз %d таңбадан аспауы керек",
+		CodePasswordLower:     "құпиясөзде кіші әріп болуы керек",
+		CodePasswordUpper:     "құпиясөзде бас әріп болуы керек",
+		CodePasswordDigit:     "құпиясөзде цифр болуы керек",
+		CodePasswordSpecial:   "құпиясөзде арнайы таңба болуы керек",
+		CodePasswordChars:     "құпиясөзде рұқсат етілмеген таңбалар бар",
+		CodePasswordPersonal:  "құпиясөзде пайдаланушы аты немесе ЖСН болмауы керек",
+		CodePasswordBreached:  "құпиясөз тым кең таралған, басқасын таңдаңыз",
+		CodePasswordWeak:      "құпиясөзді табу оңай, оны ұзартыңыз немесе болжауды қиындатыңыз",
+		CodePasswordReused:    "құпиясөз жақында қолданылған, басқасын таңдаңыз",
+		CodePasswordMismatch:  "құпиясөздер сәйкес келмейді",
+		CodePasswordIncorrect: "ағымдағы құпиясөз қате",
+		CodeIINLength:         "ЖСН 12 цифрдан тұруы керек",
+		CodeIINDigits:         "ЖСН тек цифрлардан тұруы керек",
+		CodeIINCentury:        "ЖСН-нің 7-ші цифры қате",
+		CodeIINDate:           "ЖСН-дегі туған күні жарамсыз",
+		CodeIINUnderage:       "тіркелу үшін сізге кемінде %d жас болуы керек",
+		CodeIINChecksum:       "ЖСН-нің бақылау цифры қате",
+		CodeBINLength:         "БСН 12 цифрдан тұруы керек",
+		CodeBINDigits:         "БСН тек цифрлардан тұруы керек",
+		CodeBINEntity:         "БСН-нің 5-ші немесе 6-шы цифры қате",
+		CodeBINDate:           "БСН-дегі тіркелу күні жарамсыз",
+		CodeBINChecksum:       "БСН-нің бақылау цифры қате",
+		CodeAccountType:       "тіркелгі түрі белгісіз",
+		CodeEmailInvalid:      "электрондық пошта мекенжайы қате",
+	},
+}
+
+// Message returns the message of code in lang, falling back to English and
+// then to the code itself.
+func Message(lang, code string, args ...interface{}) string {
+	format, ok := catalog[lang][code]
+	if !ok {
+		if format, ok = catalog[DefaultLanguage][code]; !ok {
+			return code
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Language picks the first catalog language of an Accept-Language header,
+// quality values are ignored as browsers list languages by preference.
+func Language(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		lang := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if _, ok := catalog[lang]; ok {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
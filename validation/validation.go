@@ -0,0 +1,148 @@
+// Package validation collects the field errors of a form so they can be
+// reported all at once, each with a stable code and a localized message.
+package validation
+
+import (
+	"errors"
+	"strings"
+)
+
+// Field error codes, clients may rely on them.
+const (
+	CodeRequired          = "required"
+	CodeUsernameChars     = "username_chars"
+	CodePasswordTooShort  = "password_too_short"
+	CodePasswordTooLong   = "password_too_long"
+	CodePasswordLower     = "password_lowercase"
+	CodePasswordUpper     = "password_uppercase"
+	CodePasswordDigit     = "password_digit"
+	CodePasswordSpecial   = "password_special"
+	CodePasswordChars     = "password_chars"
+	CodePasswordPersonal  = "password_personal"
+	CodePasswordBreached  = "password_breached"
+	CodePasswordWeak      = "password_weak"
+	CodePasswordReused    = "password_reused"
+	CodePasswordMismatch  = "password_mismatch"
+	CodePasswordIncorrect = "password_incorrect"
+	CodeIINLength         = "iin_length"
+	CodeIINDigits         = "iin_digits"
+	CodeIINCentury        = "iin_century"
+	CodeIINDate           = "iin_date"
+	CodeIINUnderage       = "iin_underage"
+	CodeIINChecksum       = "iin_checksum"
+	CodeBINLength         = "bin_length"
+	CodeBINDigits         = "bin_digits"
+	CodeBINEntity         = "bin_entity"
+	CodeBINDate           = "bin_date"
+	CodeBINChecksum       = "bin_checksum"
+	CodeAccountType       = "account_type"
+	CodeEmailInvalid      = "email_invalid"
+)
+
+// FieldError is a failed check of one field. Message is the English message,
+// Localize translates it.
+type FieldError struct {
+	Field   string        `json:"field"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Args    []interface{} `json:"-"`
+}
+
+// NewFieldError returns the error of field with the English message of code,
+// args fill the placeholders of the message.
+func NewFieldError(field, code string, args ...interface{}) *FieldError {
+	return &FieldError{
+		Field:   field,
+		Code:    code,
+		Message: Message(DefaultLanguage, code, args...),
+		Args:    args,
+	}
+}
+
+func (f *FieldError) Error() string {
+	return f.Message
+}
+
+// Errors are the field errors of a form in the order they were found.
+type Errors []FieldError
+
+// Add records err against field. A *FieldError keeps its code, Errors are
+// all recorded against field and any other error is reported with its text
+// as message.
+func (e *Errors) Add(field string, err error) {
+	if err == nil {
+		return
+	}
+	var nested Errors
+	if errors.As(err, &nested) {
+		for _, f := range nested {
+			f.Field = field
+			*e = append(*e, f)
+		}
+		return
+	}
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		f := *fe
+		f.Field = field
+		*e = append(*e, f)
+		return
+	}
+	*e = append(*e, FieldError{Field: field, Code: "invalid", Message: err.Error()})
+}
+
+// Err returns e as an error, nil when there is no field error.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, f := range e {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Has reports whether field failed a check.
+func (e Errors) Has(field string) bool {
+	for _, f := range e {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Localize returns a copy of e with the messages in lang.
+func (e Errors) Localize(lang string) Errors {
+	localized := make(Errors, len(e))
+	for i, f := range e {
+		localized[i] = f
+		if _, ok := catalog[lang][f.Code]; ok {
+			localized[i].Message = Message(lang, f.Code, f.Args...)
+		}
+	}
+	return localized
+}
+
+// ByField groups the messages of e by field, for templates.
+func (e Errors) ByField() map[string][]string {
+	fields := map[string][]string{}
+	for _, f := range e {
+		fields[f.Field] = append(fields[f.Field], f.Message)
+	}
+	return fields
+}
+
+// As returns the field errors in the chain of err.
+func As(err error) (Errors, bool) {
+	var e Errors
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
@@ -0,0 +1,70 @@
+package validation_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"transaction-service/validation"
+)
+
+func TestErrors(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var errs validation.Errors
+		errs.Add("username", nil)
+		assert.NoError(t, errs.Err())
+	})
+	t.Run("error-failed", func(t *testing.T) {
+		var errs validation.Errors
+		errs.Add("password", validation.NewFieldError("password", validation.CodePasswordTooShort, 6))
+		errs.Add("iin", validation.NewFieldError("iin", validation.CodeIINChecksum))
+		errs.Add("email", errors.New("mx lookup failed"))
+
+		err := fmt.Errorf("registration: %w", errs.Err())
+		fields, ok := validation.As(err)
+		assert.True(t, ok)
+		assert.Len(t, fields, 3)
+		assert.True(t, fields.Has("iin"))
+		assert.False(t, fields.Has("username"))
+		assert.Equal(t, "password must be at least 6 characters in length", fields[0].Message)
+		assert.Equal(t, "invalid", fields[2].Code)
+		assert.Equal(t, "password: password must be at least 6 characters in length; iin: invalid IIN: 12 digit incorrect; email: mx lookup failed", err.Error()[len("registration: "):])
+
+		ru := fields.Localize("ru")
+		assert.Equal(t, "пароль должен содержать не менее 6 символов", ru[0].Message)
+		assert.Equal(t, "mx lookup failed", ru[2].Message)
+		// the original messages are untouched
+		assert.Equal(t, "password must be at least 6 characters in length", fields[0].Message)
+
+		assert.Equal(t, map[string][]string{
+			"password": {"құпиясөз кемінде 6 таңбадан тұруы керек"},
+			"iin":      {"ЖСН-нің бақылау цифры қате"},
+			"email":    {"mx lookup failed"},
+		}, fields.Localize("kk").ByField())
+	})
+	t.Run("nested", func(t *testing.T) {
+		var inner validation.Errors
+		inner.Add("new_password", validation.NewFieldError("new_password", validation.CodePasswordUpper))
+		inner.Add("new_password", validation.NewFieldError("new_password", validation.CodePasswordDigit))
+
+		var errs validation.Errors
+		errs.Add("password", inner.Err())
+		assert.Len(t, errs, 2)
+		assert.Equal(t, []string{"password must contain an uppercase letter", "password must contain a digit"}, errs.ByField()["password"])
+	})
+}
+
+func TestLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                           "en",
+		"ru-RU,ru;q=0.9,en-US;q=0.8": "ru",
+		"de-DE, kk;q=0.7, ru;q=0.5":  "kk",
+		"fr-FR,fr;q=0.9":             "en",
+		"EN-gb":                      "en",
+	}
+	for header, lang := range cases {
+		assert.Equal(t, lang, validation.Language(header), header)
+	}
+}